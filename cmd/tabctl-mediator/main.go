@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
@@ -10,38 +11,49 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/tabctl/tabctl/internal/config"
 	"github.com/tabctl/tabctl/internal/mediator"
 )
 
 func main() {
 	var logFile string
-	flag.StringVar(&logFile, "log", "", "Log file path (default: stderr)")
+	flag.StringVar(&logFile, "log", "", "Log file path, or \"off\" (default: mediator.log_file setting)")
 	flag.Parse()
 
-	// Detect browser from arguments passed by native messaging
-	browser := detectBrowser()
+	// Detect browser and profile from the process tree and native messaging arguments
+	identity := mediator.DetectIdentity(flag.Args())
 
-	// Always log to file for debugging browser lifecycle
+	// Log to file by default for debugging browser lifecycle. A broken
+	// config file still leaves the environment.
 	if logFile == "" {
-		// Use timestamp in filename to track different sessions
-		logFile = fmt.Sprintf("/tmp/tabctl-mediator-%d.log", os.Getpid())
+		settings, _ := config.Load()
+		logFile = settings.String(config.KeyMediatorLogFile)
+	}
+	if logFile == "" {
+		logFile = config.MediatorLogFile
 	}
 
-	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		os.Exit(1)
+	if logFile == "off" {
+		log.SetOutput(io.Discard)
+	} else {
+		logFile = strings.ReplaceAll(logFile, "{pid}", fmt.Sprint(os.Getpid()))
+		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		if err != nil {
+			os.Exit(1)
+		}
+		defer file.Close()
+		log.SetOutput(file)
 	}
-	defer file.Close()
-	log.SetOutput(file)
 
 	// Always log startup and PID for debugging
-	log.Printf("Starting mediator for %s (pid=%d)", browser, os.Getpid())
+	log.Printf("Starting mediator for %s (pid=%d)", identity.Name(), os.Getpid())
 
 	// Create mediator
-	m, err := mediator.NewMediator(browser)
+	m, err := mediator.NewMediator(identity)
 	if err != nil {
 		log.Fatalf("Failed to create mediator: %v", err)
 	}
+	log.Printf("Identified browser as %s", m.Name())
 
 	// Setup signal handling - catch ALL signals for debugging
 	sigChan := make(chan os.Signal, 1)
@@ -83,25 +95,6 @@ func main() {
 	log.Printf("Shutdown complete, exiting at %s", time.Now().Format("15:04:05.000"))
 }
 
-func detectBrowser() string {
-	// Check command line arguments for browser hints
-	args := flag.Args()
-
-	for _, arg := range args {
-		// Chrome/Brave pass chrome-extension://
-		if strings.HasPrefix(arg, "chrome-extension://") {
-			return "Brave" // or detect specific Chrome variant
-		}
-		// Firefox passes manifest path
-		if strings.Contains(arg, ".mozilla/native-messaging-hosts/") {
-			return "Firefox"
-		}
-	}
-
-	// Default
-	return "Unknown"
-}
-
 func isTerminal(fd uintptr) bool {
 	// Simple check if fd is a terminal
 	_, err := os.Stdin.Stat()
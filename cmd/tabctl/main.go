@@ -7,7 +7,7 @@ import (
 )
 
 func main() {
-	if err := cli.Execute(); err != nil {
-		os.Exit(1)
+	if code := cli.ExitCode(cli.Execute()); code != 0 {
+		os.Exit(code)
 	}
-}
\ No newline at end of file
+}
@@ -10,14 +10,14 @@ const NATIVE_APP_NAME = 'tabctl_mediator';
 /**
  * Send a standardized success response to the mediator
  */
-function sendResponse(data) {
+function sendResponse(id, data) {
   // Ensure connection exists before sending
   if (!port) {
     connect();
   }
 
   if (port) {
-    const message = { result: data };
+    const message = { id: id, result: data };
     port.postMessage(message);
   }
 }
@@ -25,9 +25,9 @@ function sendResponse(data) {
 /**
  * Send a standardized error response to the mediator
  */
-function sendError(message) {
+function sendError(id, message) {
   if (port) {
-    port.postMessage({ error: message });
+    port.postMessage({ id: id, error: message });
   }
 }
 
@@ -67,10 +67,15 @@ class ChromeTabs {
     });
   }
 
-  activate(tab_id, focused) {
-    this._browser.tabs.update(tab_id, { 'active': true });
-    this._browser.tabs.get(tab_id, function (tab) {
+  activate(tab_id, focused, onSuccess, onError) {
+    this._browser.tabs.update(tab_id, { 'active': true }, (tab) => {
+      const error = this._browser.runtime.lastError;
+      if (error || !tab) {
+        onError(error ? error.message : `No tab with id: ${tab_id}`);
+        return;
+      }
       chrome.windows.update(tab.windowId, { focused: focused });
+      onSuccess(tab);
     });
   }
 
@@ -95,12 +100,34 @@ class ChromeTabs {
     }
   }
 
-  close(tab_ids, onSuccess) {
-    this._browser.tabs.remove(tab_ids, onSuccess);
+  close(tab_ids, onSuccess, onError) {
+    this._browser.tabs.remove(tab_ids, () => {
+      if (this._browser.runtime.lastError) {
+        onError(this._browser.runtime.lastError.message);
+      } else {
+        onSuccess();
+      }
+    });
+  }
+
+  move(tabId, moveOptions, onSuccess, onError) {
+    this._browser.tabs.move(tabId, moveOptions, tab => {
+      if (this._browser.runtime.lastError) {
+        onError(this._browser.runtime.lastError.message)
+      } else {
+        onSuccess(tab)
+      }
+    });
   }
 
-  move(tabId, moveOptions, onSuccess) {
-    this._browser.tabs.move(tabId, moveOptions, onSuccess);
+  moveToNewWindow(tabId, onSuccess, onError) {
+    this._browser.windows.create({ tabId: tabId }, window => {
+      if (this._browser.runtime.lastError) {
+        onError(this._browser.runtime.lastError.message)
+      } else {
+        onSuccess(window)
+      }
+    });
   }
 
   update(tabId, options, onSuccess, onError) {
@@ -114,11 +141,27 @@ class ChromeTabs {
     });
   }
 
-  create(createOptions, onSuccess) {
+  create(createOptions, onSuccess, onError) {
     if (createOptions.windowId === 0) {
-      this._browser.windows.create({ url: createOptions.url }, onSuccess);
+      const focused = createOptions.active !== false;
+      this._browser.windows.create({ url: createOptions.url, focused: focused }, (window) => {
+        if (this._browser.runtime.lastError || !window) {
+          onError(this._browser.runtime.lastError);
+          return;
+        }
+        if (createOptions.pinned) {
+          this._browser.tabs.update(window.tabs[0].id, { pinned: true });
+        }
+        onSuccess(window);
+      });
     } else {
-      this._browser.tabs.create(createOptions, onSuccess);
+      this._browser.tabs.create(createOptions, (tab) => {
+        if (this._browser.runtime.lastError || !tab) {
+          onError(this._browser.runtime.lastError);
+          return;
+        }
+        onSuccess(tab);
+      });
     }
   }
 
@@ -126,13 +169,24 @@ class ChromeTabs {
     this._browser.tabs.query({ active: true }, onSuccess);
   }
 
-  getActiveScreenshot(onSuccess) {
-    let queryOptions = { active: true, lastFocusedWindow: true };
+  getActiveScreenshot(windowId, onSuccess, onError) {
+    // Without a window ID, capture the last focused window
+    let queryOptions = windowId == null
+      ? { active: true, lastFocusedWindow: true }
+      : { active: true, windowId: windowId };
     this._browser.tabs.query(queryOptions, (tabs) => {
+      if (this._browser.runtime.lastError || tabs.length == 0) {
+        onError('No active tab to capture');
+        return;
+      }
       let tab = tabs[0];
       let windowId = tab.windowId;
       let tabId = tab.id;
-      this._browser.tabs.captureVisibleTab(windowId, { format: 'png' }, function (data) {
+      this._browser.tabs.captureVisibleTab(windowId, { format: 'png' }, (data) => {
+        if (this._browser.runtime.lastError) {
+          onError(this._browser.runtime.lastError.message);
+          return;
+        }
         const message = {
           tab: tabId,
           window: windowId,
@@ -165,7 +219,36 @@ class ChromeTabs {
   }
 
   getBrowserName() {
-    return "chrome/chromium";
+    // Brave hides itself from the brand list but exposes navigator.brave
+    if (navigator.brave) {
+      return "Brave";
+    }
+
+    const brands = (navigator.userAgentData && navigator.userAgentData.brands) || [];
+    const names = {
+      'Google Chrome': 'Chrome',
+      'Microsoft Edge': 'Edge',
+      'Opera': 'Opera',
+      'Brave': 'Brave',
+      'Vivaldi': 'Vivaldi',
+    };
+    for (const brand of brands) {
+      if (names[brand.brand]) {
+        return names[brand.brand];
+      }
+    }
+
+    const ua = navigator.userAgent;
+    if (ua.includes('Vivaldi/')) {
+      return "Vivaldi";
+    }
+    if (ua.includes('OPR/')) {
+      return "Opera";
+    }
+    if (ua.includes('Edg/')) {
+      return "Edge";
+    }
+    return "Chromium";
   }
 }
 
@@ -203,46 +286,46 @@ function compareWindowIdTabId(tabA, tabB) {
   return tabA.index - tabB.index;
 }
 
-function listTabsOnSuccess(tabs) {
+function listTabsOnSuccess(id, tabs) {
   try {
     if (!tabs || !Array.isArray(tabs)) {
-      sendError('Invalid tabs data received');
+      sendError(id, 'Invalid tabs data received');
       return;
     }
 
     // Make sure tabs are sorted by their index within a window
     tabs.sort(compareWindowIdTabId);
     const lines = tabs.map(tab => formatTabToTSV(tab));
-    sendResponse(lines);
+    sendResponse(id, lines);
   } catch (error) {
-    sendError('Failed to process tabs list');
+    sendError(id, 'Failed to process tabs list');
   }
 }
 
-function listTabs() {
-  browserTabs.list({}, listTabsOnSuccess);
+function listTabs(id) {
+  browserTabs.list({}, (tabs) => listTabsOnSuccess(id, tabs));
 }
 
-function queryTabsOnSuccess(tabs) {
+function queryTabsOnSuccess(id, tabs) {
   try {
     if (!tabs || !Array.isArray(tabs)) {
-      sendResponse([]);
+      sendResponse(id, []);
       return;
     }
 
     tabs.sort(compareWindowIdTabId);
     const lines = tabs.map(tab => formatTabToTSV(tab));
-    sendResponse(lines);
+    sendResponse(id, lines);
   } catch (error) {
-    sendError('Failed to process query results');
+    sendError(id, 'Failed to process query results');
   }
 }
 
-function queryTabsOnFailure(error) {
-  sendResponse([]);
+function queryTabsOnFailure(id, error) {
+  sendResponse(id, []);
 }
 
-function queryTabs(query_info) {
+function queryTabs(id, query_info) {
   try {
     let query = atob(query_info)
     query = JSON.parse(query)
@@ -269,88 +352,116 @@ function queryTabs(query_info) {
       return o;
     }, {})
 
-    browserTabs.query(query, queryTabsOnSuccess);
+    browserTabs.query(query, (tabs) => queryTabsOnSuccess(id, tabs));
   }
   catch (error) {
-    queryTabsOnFailure(error);
+    queryTabsOnFailure(id, error);
   }
 }
 
 
-function moveTabs(move_triplets) {
+function moveTabs(id, move_triplets) {
   // move_triplets is a tuple of (tab_id, window_id, new_index)
   if (move_triplets.length == 0) {
     // this post is only required to make bt move command synchronous. mediator
     // is waiting for any reply
-    sendResponse('OK');
+    sendResponse(id, 'OK');
     return
   }
 
   // we request a move of a single tab and when it happens, we call ourselves
   // again with the remaining tabs (first omitted)
   const [tabId, windowId, index] = move_triplets[0];
+  const rest = move_triplets.slice(1);
+
+  // window ID 0 asks for a new window: the first such tab opens it and the
+  // remaining ones follow it there
+  if (windowId === 0) {
+    browserTabs.moveToNewWindow(tabId,
+      (window) => moveTabs(id, rest.map(([t, w, i]) => [t, w === 0 ? window.id : w, i])),
+      (error) => sendError(id, `Failed to move tab ${tabId} to a new window`)
+    );
+    return;
+  }
+
   browserTabs.move(tabId, { index: index, windowId: windowId },
-    (tab) => moveTabs(move_triplets.slice(1))
+    (tab) => moveTabs(id, rest),
+    (error) => sendError(id, `Failed to move tab ${tabId}`)
   );
 }
 
-function closeTabs(tab_ids) {
+function closeTabs(id, tab_ids) {
   try {
     if (!tab_ids || !Array.isArray(tab_ids)) {
-      sendError('Invalid tab_ids parameter');
+      sendError(id, 'Invalid tab_ids parameter');
       return;
     }
 
     // Parse full tab IDs to extract just the numeric tab ID
-    const numericIds = tab_ids.map(id => parseTabId(id));
-    browserTabs.close(numericIds, () => sendResponse('OK'));
+    const numericIds = tab_ids.map(tabId => parseTabId(tabId));
+    browserTabs.close(numericIds,
+      () => sendResponse(id, 'OK'),
+      (error) => sendError(id, `Failed to close tabs: ${error}`)
+    );
   } catch (error) {
-    sendError('Failed to close tabs');
+    sendError(id, 'Failed to close tabs');
   }
 }
 
-function openUrls(urls, window_id, first_result = "") {
+function openUrls(id, urls, window_id, options = {}, first_result = "") {
+  // options: { active: bool, pinned: bool }; tabs are active unless told otherwise
+  const active = options['active'] !== false;
+  const pinned = !!options['pinned'];
+
   if (urls.length == 0) {
-    sendResponse([]);
+    sendResponse(id, first_result !== "" ? [first_result] : []);
     return;
   }
 
+  // URLs the browser refuses to open are skipped so the others still open
   if (window_id === 0) {
-    browserTabs.create({ 'url': urls[0], windowId: 0 }, (window) => {
-      result = `${window.id}.${window.tabs[0].id}`;
-      urls = urls.slice(1);
-      openUrls(urls, window.id, result);
-    });
+    browserTabs.create({ 'url': urls[0], windowId: 0, active: active, pinned: pinned },
+      (window) => {
+        result = `c.${window.id}.${window.tabs[0].id}`;
+        urls = urls.slice(1);
+        openUrls(id, urls, window.id, options, result);
+      },
+      (error) => openUrls(id, urls.slice(1), 0, options, first_result)
+    );
     return;
   }
 
   var promises = [];
   for (let url of urls) {
     promises.push(new Promise((resolve, reject) => {
-      browserTabs.create({ 'url': url, windowId: window_id },
-        (tab) => resolve(`${tab.windowId}.${tab.id}`)
+      browserTabs.create({ 'url': url, windowId: window_id, active: active, pinned: pinned },
+        (tab) => resolve(`c.${tab.windowId}.${tab.id}`),
+        (error) => resolve(null)
       );
     }))
   };
   Promise.all(promises).then(result => {
+    result = result.filter((tabId) => tabId !== null);
     if (first_result !== "") {
       result.unshift(first_result);
     }
     const data = Array.prototype.concat(...result)
-    sendResponse(data);
+    sendResponse(id, data);
   });
 }
 
-function createTab(url) {
+function createTab(id, url) {
   browserTabs.create({ 'url': url },
     (tab) => {
-      sendResponse([`${tab.windowId}.${tab.id}`]);
-    });
+      sendResponse(id, [`c.${tab.windowId}.${tab.id}`]);
+    },
+    (error) => sendError(id, `Failed to create tab: ${error && error.message}`)
+  );
 }
 
-function updateTabs(updates) {
+function updateTabs(id, updates) {
   if (updates.length == 0) {
-    sendResponse([]);
+    sendResponse(id, []);
     return;
   }
 
@@ -358,7 +469,7 @@ function updateTabs(updates) {
   for (let update of updates) {
     promises.push(new Promise((resolve, reject) => {
       browserTabs.update(update.tab_id, update.properties,
-        (tab) => { resolve(`${tab.windowId}.${tab.id}`) },
+        (tab) => { resolve(`c.${tab.windowId}.${tab.id}`) },
         (error) => {
           // Could not update tab
           resolve()
@@ -368,28 +479,31 @@ function updateTabs(updates) {
   };
   Promise.all(promises).then(result => {
     const data = Array.prototype.concat(...result).filter(x => !!x)
-    sendResponse(data);
+    sendResponse(id, data);
   });
 }
 
-function activateTab(tab_id, focused) {
+function activateTab(id, tab_id, focused) {
   // Convert string tab ID to integer for Chrome API
   const tabIdInt = parseTabId(tab_id);
-  browserTabs.activate(tabIdInt, focused);
-  sendResponse('OK');
+  browserTabs.activate(tabIdInt, focused,
+    (tab) => sendResponse(id, 'OK'),
+    (error) => sendError(id, `Failed to activate tab ${tab_id}: ${error}`)
+  );
 }
 
-function getActiveTabs() {
+function getActiveTabs(id) {
   browserTabs.getActive(tabs => {
-    var result = tabs.map(tab => tab.windowId + "." + tab.id).toString()
-    sendResponse(result);
+    var result = tabs.map(tab => `c.${tab.windowId}.${tab.id}`).toString()
+    sendResponse(id, result);
   });
 }
 
-function getActiveScreenshot() {
-  browserTabs.getActiveScreenshot(data => {
-    sendResponse(data);
-  });
+function getActiveScreenshot(id, window_id) {
+  browserTabs.getActiveScreenshot(window_id,
+    (data) => sendResponse(id, data),
+    (error) => sendError(id, `Failed to get screenshot: ${error}`)
+  );
 }
 
 function getWordsScript(match_regex, join_with) {
@@ -417,7 +531,7 @@ function listOr(list, default_value) {
   return list;
 }
 
-function getWordsFromTabs(tabs, match_regex, join_with) {
+function getWordsFromTabs(id, tabs, match_regex, join_with) {
   var promises = [];
   const script = getWordsScript(match_regex, join_with);
 
@@ -436,20 +550,21 @@ function getWordsFromTabs(tabs, match_regex, join_with) {
   Promise.all(promises).then(
     (all_words) => {
       const result = Array.prototype.concat(...all_words);
-      sendResponse(result);
+      sendResponse(id, result);
     }
   )
 }
 
-function getWords(tab_id, match_regex, join_with) {
+function getWords(id, tab_id, match_regex, join_with) {
   if (tab_id == null) {
     browserTabs.getActive(
-      (tabs) => getWordsFromTabs(tabs, match_regex, join_with),
+      (tabs) => getWordsFromTabs(id, tabs, match_regex, join_with),
     );
   } else {
     const script = getWordsScript(match_regex, join_with);
     browserTabs.runScript(tab_id, script, null,
-      (words, _payload) => sendResponse(listOr(words, []))
+      (words, _payload) => sendResponse(id, listOr(words, [])),
+      (error, _payload) => sendError(id, `Failed to get words of tab ${tab_id}: ${error}`)
     );
   }
 }
@@ -479,44 +594,44 @@ function getTextOrHtmlFromTabs(tabs, scriptGetter, delimiter_regex, replace_with
   Promise.all(promises).then(onSuccess);
 }
 
-function getTextOnRunScriptSuccess(all_results) {
+function getTextOnRunScriptSuccess(id, all_results) {
   lines = [];
   for (let result of all_results) {
     tab = result['tab'];
     text = result['text'];
-    let line = tab.windowId + "." + tab.id + "\t" + tab.title + "\t" + tab.url + "\t" + text;
+    let line = `c.${tab.windowId}.${tab.id}\t${tab.title}\t${tab.url}\t${text}`;
     lines.push(line);
   }
-  sendResponse(lines);
+  sendResponse(id, lines);
 }
 
-function getTextOnListSuccess(tabs, delimiter_regex, replace_with) {
+function getTextOnListSuccess(id, tabs, delimiter_regex, replace_with) {
   // Make sure tabs are sorted by their index within a window
   tabs.sort(compareWindowIdTabId);
-  getTextOrHtmlFromTabs(tabs, getTextScript, delimiter_regex, replace_with, getTextOnRunScriptSuccess);
+  getTextOrHtmlFromTabs(tabs, getTextScript, delimiter_regex, replace_with, (results) => getTextOnRunScriptSuccess(id, results));
 }
 
-function getText(delimiter_regex, replace_with) {
+function getText(id, delimiter_regex, replace_with) {
   browserTabs.list({ 'discarded': false },
-    (tabs) => getTextOnListSuccess(tabs, delimiter_regex, replace_with),
+    (tabs) => getTextOnListSuccess(id, tabs, delimiter_regex, replace_with),
   );
 }
 
-function getHtmlOnListSuccess(tabs, delimiter_regex, replace_with) {
+function getHtmlOnListSuccess(id, tabs, delimiter_regex, replace_with) {
   // Make sure tabs are sorted by their index within a window
   tabs.sort(compareWindowIdTabId);
-  getTextOrHtmlFromTabs(tabs, getHtmlScript, delimiter_regex, replace_with, getTextOnRunScriptSuccess);
+  getTextOrHtmlFromTabs(tabs, getHtmlScript, delimiter_regex, replace_with, (results) => getTextOnRunScriptSuccess(id, results));
 }
 
-function getHtml(delimiter_regex, replace_with) {
+function getHtml(id, delimiter_regex, replace_with) {
   browserTabs.list({ 'discarded': false },
-    (tabs) => getHtmlOnListSuccess(tabs, delimiter_regex, replace_with),
+    (tabs) => getHtmlOnListSuccess(id, tabs, delimiter_regex, replace_with),
   );
 }
 
-function getBrowserName() {
+function getBrowserName(id) {
   const name = browserTabs.getBrowserName();
-  sendResponse(name);
+  sendResponse(id, name);
 }
 
 function handleMessage(command) {
@@ -525,59 +640,62 @@ function handleMessage(command) {
     connect();
   }
 
+  // Echo the command ID on every reply so the mediator can match it to the caller
+  const id = command['id'];
+
   if (command['name'] == 'list_tabs') {
-    listTabs();
+    listTabs(id);
   }
   else if (command['name'] == 'query_tabs') {
-    queryTabs(command['args']['query_info']);
+    queryTabs(id, command['args']['query_info']);
   }
 
   else if (command['name'] == 'close_tabs') {
-    closeTabs(command['args']['tab_ids']);
+    closeTabs(id, command['args']['tab_ids']);
   }
 
   else if (command['name'] == 'move_tabs') {
-    moveTabs(command['args']['move_triplets']);
+    moveTabs(id, command['args']['move_triplets']);
   }
 
   else if (command['name'] == 'open_urls') {
-    openUrls(command['args']['urls'], command['args']['window_id']);
+    openUrls(id, command['args']['urls'], command['args']['window_id'], command['args']['options']);
   }
 
   else if (command['name'] == 'new_tab') {
-    createTab(command['args']['url']);
+    createTab(id, command['args']['url']);
   }
 
   else if (command['name'] == 'update_tabs') {
-    updateTabs(command['args']['updates']);
+    updateTabs(id, command['args']['updates']);
   }
 
   else if (command['name'] == 'activate_tab') {
-    activateTab(command['args']['tab_id'], !!command['args']['focused']);
+    activateTab(id, command['args']['tab_id'], !!command['args']['focused']);
   }
 
   else if (command['name'] == 'get_active_tabs') {
-    getActiveTabs();
+    getActiveTabs(id);
   }
 
   else if (command['name'] == 'get_screenshot') {
-    getActiveScreenshot();
+    getActiveScreenshot(id, command['args'] ? command['args']['window_id'] : null);
   }
 
   else if (command['name'] == 'get_words') {
-    getWords(command['tab_id'], command['match_regex'], command['join_with']);
+    getWords(id, command['args']['tab_id'], command['args']['match_regex'], command['args']['join_with']);
   }
 
   else if (command['name'] == 'get_text') {
-    getText(command['delimiter_regex'], command['replace_with']);
+    getText(id, command['args']['delimiter_regex'], command['args']['replace_with']);
   }
 
   else if (command['name'] == 'get_html') {
-    getHtml(command['delimiter_regex'], command['replace_with']);
+    getHtml(id, command['args']['delimiter_regex'], command['args']['replace_with']);
   }
 
   else if (command['name'] == 'get_browser') {
-    getBrowserName();
+    getBrowserName(id);
   }
 }
 
@@ -591,3 +709,84 @@ function handleDisconnect() {
   }, 1000);
 }
 
+// ============================================================================
+// EVENT STREAM
+// ============================================================================
+
+/**
+ * Push an unsolicited tab/window event to the mediator
+ */
+function sendEvent(event, data) {
+  if (!port) {
+    return;
+  }
+
+  try {
+    port.postMessage(Object.assign({ type: 'event', event: event }, data));
+  } catch (error) {
+    // Port went away; the event is simply lost
+  }
+}
+
+function tabEventData(tab) {
+  return {
+    tab_id: `c.${tab.windowId}.${tab.id}`,
+    window_id: tab.windowId,
+    index: tab.index,
+    title: tab.title,
+    url: tab.url,
+    active: tab.active,
+    pinned: tab.pinned
+  };
+}
+
+chrome.tabs.onCreated.addListener((tab) => {
+  sendEvent('tab_created', tabEventData(tab));
+});
+
+chrome.tabs.onRemoved.addListener((tabId, removeInfo) => {
+  sendEvent('tab_removed', {
+    tab_id: `c.${removeInfo.windowId}.${tabId}`,
+    window_id: removeInfo.windowId
+  });
+});
+
+chrome.tabs.onUpdated.addListener((tabId, changeInfo, tab) => {
+  // Only report changes that affect what tabctl shows
+  if (changeInfo.title === undefined && changeInfo.url === undefined &&
+      changeInfo.pinned === undefined && changeInfo.status !== 'complete') {
+    return;
+  }
+  sendEvent('tab_updated', tabEventData(tab));
+});
+
+chrome.tabs.onActivated.addListener((activeInfo) => {
+  sendEvent('tab_activated', {
+    tab_id: `c.${activeInfo.windowId}.${activeInfo.tabId}`,
+    window_id: activeInfo.windowId
+  });
+});
+
+chrome.tabs.onMoved.addListener((tabId, moveInfo) => {
+  sendEvent('tab_moved', {
+    tab_id: `c.${moveInfo.windowId}.${tabId}`,
+    window_id: moveInfo.windowId,
+    from_index: moveInfo.fromIndex,
+    to_index: moveInfo.toIndex
+  });
+});
+
+chrome.tabs.onAttached.addListener((tabId, attachInfo) => {
+  // A tab dragged into another window is reported as a move with no origin index
+  sendEvent('tab_moved', {
+    tab_id: `c.${attachInfo.newWindowId}.${tabId}`,
+    window_id: attachInfo.newWindowId,
+    from_index: -1,
+    to_index: attachInfo.newPosition
+  });
+});
+
+chrome.windows.onFocusChanged.addListener((windowId) => {
+  // windowId is WINDOW_ID_NONE (-1) when every browser window lost focus
+  sendEvent('window_focused', { window_id: windowId });
+});
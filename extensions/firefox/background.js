@@ -13,14 +13,14 @@ const GET_HTML_SCRIPT = 'document.documentElement.innerHTML.replace(#delimiter_r
 /**
  * Send a standardized success response to the mediator
  */
-function sendResponse(data) {
+function sendResponse(id, data) {
   if (!port) {
     
     return;
   }
 
   try {
-    port.postMessage({result: data});
+    port.postMessage({id: id, result: data});
   } catch (error) {
     
   }
@@ -29,14 +29,14 @@ function sendResponse(data) {
 /**
  * Send a standardized error response to the mediator
  */
-function sendError(message) {
+function sendError(id, message) {
   if (!port) {
     
     return;
   }
 
   try {
-    port.postMessage({error: message});
+    port.postMessage({id: id, error: message});
   } catch (error) {
     
   }
@@ -95,23 +95,27 @@ class BrowserTabs {
     throw new Error('query is not implemented');
   }
 
-  close(tab_ids, onSuccess) {
+  close(tab_ids, onSuccess, onError) {
     throw new Error('close is not implemented');
   }
 
-  move(tabId, moveOptions, onSuccess) {
+  move(tabId, moveOptions, onSuccess, onError) {
     throw new Error('move is not implemented');
   }
 
+  moveToNewWindow(tabId, onSuccess, onError) {
+    throw new Error('moveToNewWindow is not implemented');
+  }
+
   update(tabId, options, onSuccess, onError) {
     throw new Error('update is not implemented');
   }
 
-  create(createOptions, onSuccess) {
+  create(createOptions, onSuccess, onError) {
     throw new Error('create is not implemented');
   }
 
-  activate(tab_id) {
+  activate(tab_id, focused, onSuccess, onError) {
     throw new Error('activate is not implemented');
   }
 
@@ -119,7 +123,7 @@ class BrowserTabs {
     throw new Error('getActive is not implemented');
   }
 
-  getActiveScreenshot(onSuccess) {
+  getActiveScreenshot(windowId, onSuccess, onError) {
     throw new Error('getActiveScreenshot is not implemented');
   }
 
@@ -127,7 +131,7 @@ class BrowserTabs {
     throw new Error('runScript is not implemented');
   }
 
-  getBrowserName() {
+  getBrowserName(onSuccess, onError) {
     throw new Error('getBrowserName is not implemented');
   }
 }
@@ -167,17 +171,24 @@ class FirefoxTabs extends BrowserTabs {
     }
   }
 
-  close(tab_ids, onSuccess) {
+  close(tab_ids, onSuccess, onError) {
     this._browser.tabs.remove(tab_ids).then(
       onSuccess,
-      (error) => { /* Error removing tab */ }
+      (error) => onError(error)
     );
   }
 
-  move(tabId, moveOptions, onSuccess) {
+  move(tabId, moveOptions, onSuccess, onError) {
     this._browser.tabs.move(tabId, moveOptions).then(
       onSuccess,
-      (error) => { /* Error moving tab */ }
+      (error) => onError(error)
+    );
+  }
+
+  moveToNewWindow(tabId, onSuccess, onError) {
+    this._browser.windows.create({tabId: tabId}).then(
+      onSuccess,
+      (error) => onError(error)
     );
   }
 
@@ -191,16 +202,22 @@ class FirefoxTabs extends BrowserTabs {
     );
   }
 
-  create(createOptions, onSuccess) {
+  create(createOptions, onSuccess, onError) {
     if (createOptions.windowId === 0) {
-      this._browser.windows.create({ url: createOptions.url }).then(
-        onSuccess,
-        (error) => { /* Error in tab operation */ }
+      const focused = createOptions.active !== false;
+      this._browser.windows.create({url: createOptions.url, focused: focused}).then(
+        (window) => {
+          if (createOptions.pinned) {
+            this._browser.tabs.update(window.tabs[0].id, {pinned: true});
+          }
+          onSuccess(window);
+        },
+        (error) => onError(error)
       );
     } else {
       this._browser.tabs.create(createOptions).then(
         onSuccess,
-        (error) => { /* Error in tab operation */ }
+        (error) => onError(error)
       );
     }
   }
@@ -212,10 +229,17 @@ class FirefoxTabs extends BrowserTabs {
     );
   }
 
-  getActiveScreenshot(onSuccess) {
-    let queryOptions = { active: true, lastFocusedWindow: true };
+  getActiveScreenshot(windowId, onSuccess, onError) {
+    // Without a window ID, capture the last focused window
+    let queryOptions = windowId == null
+      ? { active: true, lastFocusedWindow: true }
+      : { active: true, windowId: windowId };
     this._browser.tabs.query(queryOptions).then(
       (tabs) => {
+        if (tabs.length == 0) {
+          onError('No active tab to capture');
+          return;
+        }
         let tab = tabs[0];
         let windowId = tab.windowId;
         let tabId = tab.id;
@@ -228,10 +252,10 @@ class FirefoxTabs extends BrowserTabs {
             };
             onSuccess(message);
           },
-          (error) => { /* Error in tab operation */ }
+          (error) => onError(error)
         );
       },
-      (error) => { /* Error in tab operation */ }
+      (error) => onError(error)
     );
   }
 
@@ -242,11 +266,15 @@ class FirefoxTabs extends BrowserTabs {
     );
   }
 
-  getBrowserName() {
-      return "firefox";
+  getBrowserName(onSuccess, onError) {
+    // Forks such as Zen or LibreWolf report their own name here
+    this._browser.runtime.getBrowserInfo().then(
+      (info) => onSuccess(info.name),
+      (error) => onError(error)
+    );
   }
 
-  activate(tab_id, focused) {
+  activate(tab_id, focused, onSuccess, onError) {
     
 
     this._browser.tabs.update(tab_id, {'active': true}).then(
@@ -258,11 +286,9 @@ class FirefoxTabs extends BrowserTabs {
             (error) => { /* Error focusing window */ }
           );
         }
+        onSuccess(tab);
       },
-      (error) => {
-        
-        
-      }
+      (error) => onError(error)
     );
   }
 }
@@ -317,13 +343,13 @@ function compareWindowIdTabId(tabA, tabB) {
   return tabA.index - tabB.index;
 }
 
-function listTabsOnSuccess(tabs) {
+function listTabsOnSuccess(id, tabs) {
   try {
     
 
     if (!tabs || !Array.isArray(tabs)) {
       
-      sendError('Invalid tabs data received');
+      sendError(id, 'Invalid tabs data received');
       return;
     }
 
@@ -336,41 +362,41 @@ function listTabsOnSuccess(tabs) {
     });
 
     
-    sendResponse(lines);
+    sendResponse(id, lines);
   } catch (error) {
     
     
-    sendError('Failed to process tabs list');
+    sendError(id, 'Failed to process tabs list');
   }
 }
 
-function listTabs() {
-  browserTabs.list({}, listTabsOnSuccess);
+function listTabs(id) {
+  browserTabs.list({}, (tabs) => listTabsOnSuccess(id, tabs));
 }
 
-function queryTabsOnSuccess(tabs) {
+function queryTabsOnSuccess(id, tabs) {
   try {
     if (!tabs || !Array.isArray(tabs)) {
       
-      sendResponse([]);
+      sendResponse(id, []);
       return;
     }
 
     tabs.sort(compareWindowIdTabId);
     const lines = tabs.map(tab => formatTabToTSV(tab));
-    sendResponse(lines);
+    sendResponse(id, lines);
   } catch (error) {
     
-    sendError('Failed to process query results');
+    sendError(id, 'Failed to process query results');
   }
 }
 
-function queryTabsOnFailure(error) {
+function queryTabsOnFailure(id, error) {
   
-  sendResponse([]);
+  sendResponse(id, []);
 }
 
-function queryTabs(query_info) {
+function queryTabs(id, query_info) {
   try {
     let query = atob(query_info)
     query = JSON.parse(query)
@@ -395,73 +421,95 @@ function queryTabs(query_info) {
       return o;
     }, {})
 
-    browserTabs.query(query, queryTabsOnSuccess);
+    browserTabs.query(query, (tabs) => queryTabsOnSuccess(id, tabs));
   }
   catch(error) {
-    queryTabsOnFailure(error);
+    queryTabsOnFailure(id, error);
   }
 }
 
 
-function moveTabs(move_triplets) {
+function moveTabs(id, move_triplets) {
   // move_triplets is a tuple of (tab_id, window_id, new_index)
   if (move_triplets.length == 0) {
     // this post is only required to make bt move command synchronous. mediator
     // is waiting for any reply
-    sendResponse('OK');
+    sendResponse(id, 'OK');
     return
   }
 
   // we request a move of a single tab and when it happens, we call ourselves
   // again with the remaining tabs (first omitted)
   const [tabId, windowId, index] = move_triplets[0];
+  const rest = move_triplets.slice(1);
+
+  // window ID 0 asks for a new window: the first such tab opens it and the
+  // remaining ones follow it there
+  if (windowId === 0) {
+    browserTabs.moveToNewWindow(tabId,
+      (window) => moveTabs(id, rest.map(([t, w, i]) => [t, w === 0 ? window.id : w, i])),
+      (error) => sendError(id, `Failed to move tab ${tabId} to a new window`)
+    );
+    return;
+  }
+
   browserTabs.move(tabId, {index: index, windowId: windowId},
-    (tab) => moveTabs(move_triplets.slice(1))
+    (tab) => moveTabs(id, rest),
+    (error) => sendError(id, `Failed to move tab ${tabId}`)
   );
 }
 
-function closeTabs(tab_ids) {
+function closeTabs(id, tab_ids) {
   
   try {
     if (!tab_ids || !Array.isArray(tab_ids)) {
       
-      sendError('Invalid tab_ids parameter');
+      sendError(id, 'Invalid tab_ids parameter');
       return;
     }
 
     // Parse full tab IDs to extract just the numeric tab ID
-    const numericIds = tab_ids.map(id => {
-      const parsed = parseTabId(id);
+    const numericIds = tab_ids.map(tabId => {
+      const parsed = parseTabId(tabId);
       
       return parsed;
     });
 
     
-    browserTabs.close(numericIds, () => {
-      
-      sendResponse('OK');
-    });
+    browserTabs.close(numericIds,
+      () => sendResponse(id, 'OK'),
+      (error) => sendError(id, `Failed to close tabs: ${error}`)
+    );
   } catch (error) {
     
     
-    sendError('Failed to close tabs: ' + error.message);
+    sendError(id, 'Failed to close tabs: ' + error.message);
   }
 }
 
-function openUrls(urls, window_id, first_result="") {
+function openUrls(id, urls, window_id, options = {}, first_result="") {
+  // options: {active: bool, pinned: bool}; tabs are active unless told otherwise
+  const active = options['active'] !== false;
+  const pinned = !!options['pinned'];
+
   try {
     if (urls.length == 0) {
-      sendResponse([]);
+      sendResponse(id, first_result !== "" ? [first_result] : []);
       return;
     }
 
+  // Firefox refuses privileged URLs such as about:preferences or file:
+  // ones; those are skipped so the others still open
   if (window_id === 0) {
-    browserTabs.create({'url': urls[0], windowId: 0}, (window) => {
-      result = `f.${window.id}.${window.tabs[0].id}`;
-      
-      urls = urls.slice(1);
-      openUrls(urls, window.id, result);
-    });
+    browserTabs.create({'url': urls[0], windowId: 0, active: active, pinned: pinned},
+      (window) => {
+        result = `f.${window.id}.${window.tabs[0].id}`;
+        
+        urls = urls.slice(1);
+        openUrls(id, urls, window.id, options, result);
+      },
+      (error) => openUrls(id, urls.slice(1), 0, options, first_result)
+    );
     return;
   }
 
@@ -469,39 +517,43 @@ function openUrls(urls, window_id, first_result="") {
   for (let url of urls) {
     
     promises.push(new Promise((resolve, reject) => {
-      browserTabs.create({'url': url, windowId: window_id},
-        (tab) => resolve(`f.${tab.windowId}.${tab.id}`)
+      browserTabs.create({'url': url, windowId: window_id, active: active, pinned: pinned},
+        (tab) => resolve(`f.${tab.windowId}.${tab.id}`),
+        (error) => resolve(null)
       );
     }))
   };
   Promise.all(promises).then(result => {
+    result = result.filter((tabId) => tabId !== null);
     if (first_result !== "") {
       result.unshift(first_result);
     }
     const data = Array.prototype.concat(...result)
-    sendResponse(data);
+    sendResponse(id, data);
   });
   } catch (error) {
     
-    sendError('Failed to open URLs');
+    sendError(id, 'Failed to open URLs');
   }
 }
 
-function createTab(url) {
+function createTab(id, url) {
   try {
     browserTabs.create({'url': url},
       (tab) => {
-        sendResponse([`f.${tab.windowId}.${tab.id}`]);
-    });
+        sendResponse(id, [`f.${tab.windowId}.${tab.id}`]);
+      },
+      (error) => sendError(id, `Failed to create tab: ${error}`)
+    );
   } catch (error) {
     
-    sendError('Failed to create tab');
+    sendError(id, 'Failed to create tab');
   }
 }
 
-function updateTabs(updates) {
+function updateTabs(id, updates) {
   if (updates.length == 0) {
-    sendResponse([]);
+    sendResponse(id, []);
     return;
   }
 
@@ -520,11 +572,11 @@ function updateTabs(updates) {
   };
   Promise.all(promises).then(result => {
     const data = Array.prototype.concat(...result).filter(x => !!x)
-    sendResponse(data);
+    sendResponse(id, data);
   });
 }
 
-function activateTab(tab_id, focused) {
+function activateTab(id, tab_id, focused) {
   
   try {
     // Convert string tab ID to integer for Firefox API
@@ -533,40 +585,42 @@ function activateTab(tab_id, focused) {
 
     if (isNaN(tabIdInt)) {
       
-      sendError(`Invalid tab ID: ${tab_id}`);
+      sendError(id, `Invalid tab ID: ${tab_id}`);
       return;
     }
 
-    browserTabs.activate(tabIdInt, focused);
-    
-    sendResponse('OK');
+    browserTabs.activate(tabIdInt, focused,
+      (tab) => sendResponse(id, 'OK'),
+      (error) => sendError(id, `Failed to activate tab ${tab_id}: ${error}`)
+    );
   } catch (error) {
     
     
-    sendError('Failed to activate tab: ' + error.message);
+    sendError(id, 'Failed to activate tab: ' + error.message);
   }
 }
 
-function getActiveTabs() {
+function getActiveTabs(id) {
   try {
     browserTabs.getActive(tabs => {
         var result = tabs.map(tab => `f.${tab.windowId}.${tab.id}`).toString()
-        sendResponse(result);
+        sendResponse(id, result);
     });
   } catch (error) {
     
-    sendError('Failed to get active tabs');
+    sendError(id, 'Failed to get active tabs');
   }
 }
 
-function getActiveScreenshot() {
+function getActiveScreenshot(id, window_id) {
   try {
-    browserTabs.getActiveScreenshot(data => {
-      sendResponse(data);
-    });
+    browserTabs.getActiveScreenshot(window_id,
+      (data) => sendResponse(id, data),
+      (error) => sendError(id, `Failed to get screenshot: ${error}`)
+    );
   } catch (error) {
     
-    sendError('Failed to get screenshot');
+    sendError(id, 'Failed to get screenshot');
   }
 }
 
@@ -595,7 +649,7 @@ function listOr(list, default_value) {
   return list;
 }
 
-function getWordsFromTabs(tabs, match_regex, join_with) {
+function getWordsFromTabs(id, tabs, match_regex, join_with) {
   var promises = [];
   
   const script = getWordsScript(match_regex, join_with);
@@ -620,23 +674,23 @@ function getWordsFromTabs(tabs, match_regex, join_with) {
     (all_words) => {
       const result = Array.prototype.concat(...all_words);
       
-      sendResponse(result);
+      sendResponse(id, result);
     }
   )
 }
 
-function getWords(tab_id, match_regex, join_with) {
+function getWords(id, tab_id, match_regex, join_with) {
   if (tab_id == null) {
     
     browserTabs.getActive(
-      (tabs) => getWordsFromTabs(tabs, match_regex, join_with),
+      (tabs) => getWordsFromTabs(id, tabs, match_regex, join_with),
     );
   } else {
     const script = getWordsScript(match_regex, join_with);
     
     browserTabs.runScript(tab_id, script, null,
-      (words, _payload) => sendResponse(listOr(words, [])),
-      (error, _payload) => { /* Could not run script */ },
+      (words, _payload) => sendResponse(id, listOr(words, [])),
+      (error, _payload) => sendError(id, `Failed to get words of tab ${tab_id}: ${error}`),
     );
   }
 }
@@ -675,7 +729,7 @@ function getTextOrHtmlFromTabs(tabs, scriptGetter, delimiter_regex, replace_with
   Promise.all(promises).then(onSuccess);
 }
 
-function getTextOnRunScriptSuccess(all_results) {
+function getTextOnRunScriptSuccess(id, all_results) {
   
   
   // 
@@ -689,41 +743,38 @@ function getTextOnRunScriptSuccess(all_results) {
     lines.push(line);
   }
   // lines = lines.sort(naturalCompare);
-  sendResponse(lines);
+  sendResponse(id, lines);
 }
 
-function getTextOnListSuccess(tabs, delimiter_regex, replace_with) {
+function getTextOnListSuccess(id, tabs, delimiter_regex, replace_with) {
   // Make sure tabs are sorted by their index within a window
   tabs.sort(compareWindowIdTabId);
-  getTextOrHtmlFromTabs(tabs, getTextScript, delimiter_regex, replace_with, getTextOnRunScriptSuccess);
+  getTextOrHtmlFromTabs(tabs, getTextScript, delimiter_regex, replace_with, (results) => getTextOnRunScriptSuccess(id, results));
 }
 
-function getText(delimiter_regex, replace_with) {
+function getText(id, delimiter_regex, replace_with) {
   browserTabs.list({'discarded': false},
-      (tabs) => getTextOnListSuccess(tabs, delimiter_regex, replace_with),
+      (tabs) => getTextOnListSuccess(id, tabs, delimiter_regex, replace_with),
   );
 }
 
-function getHtmlOnListSuccess(tabs, delimiter_regex, replace_with) {
+function getHtmlOnListSuccess(id, tabs, delimiter_regex, replace_with) {
   // Make sure tabs are sorted by their index within a window
   tabs.sort(compareWindowIdTabId);
-  getTextOrHtmlFromTabs(tabs, getHtmlScript, delimiter_regex, replace_with, getTextOnRunScriptSuccess);
+  getTextOrHtmlFromTabs(tabs, getHtmlScript, delimiter_regex, replace_with, (results) => getTextOnRunScriptSuccess(id, results));
 }
 
-function getHtml(delimiter_regex, replace_with) {
+function getHtml(id, delimiter_regex, replace_with) {
   browserTabs.list({'discarded': false},
-      (tabs) => getHtmlOnListSuccess(tabs, delimiter_regex, replace_with),
+      (tabs) => getHtmlOnListSuccess(id, tabs, delimiter_regex, replace_with),
   );
 }
 
-function getBrowserName() {
-  try {
-    const name = browserTabs.getBrowserName();
-    sendResponse(name);
-  } catch (error) {
-    
-    sendError('Failed to get browser name');
-  }
+function getBrowserName(id) {
+  browserTabs.getBrowserName(
+    (name) => sendResponse(id, name),
+    (error) => sendError(id, 'Failed to get browser name'),
+  );
 }
 
 function handleMessage(command) {
@@ -740,78 +791,81 @@ function handleMessage(command) {
     return;
   }
 
+  // Echo the command ID on every reply so the mediator can match it to the caller
+  const id = command['id'];
+
   if (command['name'] == 'list_tabs') {
     // For Firefox, immediately return tab data instead of waiting for CLI request
     browserTabs.list({}, (tabs) => {
       if (!tabs || !Array.isArray(tabs)) {
-        sendError('Invalid tabs data received');
+        sendError(id, 'Invalid tabs data received');
         return;
       }
       tabs.sort(compareWindowIdTabId);
       const lines = tabs.map(tab => formatTabToTSV(tab));
-      sendResponse(lines);
+      sendResponse(id, lines);
     });
   }
 
   else if (command['name'] == 'query_tabs') {
-    queryTabs(command['args']['query_info']);
+    queryTabs(id, command['args']['query_info']);
   }
 
   else if (command['name'] == 'close_tabs') {
-    closeTabs(command['args']['tab_ids']);
+    closeTabs(id, command['args']['tab_ids']);
   }
 
   else if (command['name'] == 'move_tabs') {
     
-    moveTabs(command['args']['move_triplets']);
+    moveTabs(id, command['args']['move_triplets']);
   }
 
   else if (command['name'] == 'open_urls') {
     
-    openUrls(command['args']['urls'], command['args']['window_id']);
+    openUrls(id, command['args']['urls'], command['args']['window_id'], command['args']['options']);
   }
 
   else if (command['name'] == 'new_tab') {
     
-    createTab(command['args']['url']);
+    createTab(id, command['args']['url']);
   }
 
   else if (command['name'] == 'update_tabs') {
     
-    updateTabs(command['args']['updates']);
+    updateTabs(id, command['args']['updates']);
   }
 
   else if (command['name'] == 'activate_tab') {
-    activateTab(command['args']['tab_id'], !!command['args']['focused']);
+    activateTab(id, command['args']['tab_id'], !!command['args']['focused']);
   }
 
   else if (command['name'] == 'get_active_tabs') {
     
-    getActiveTabs();
+    getActiveTabs(id);
   }
 
   else if (command['name'] == 'get_screenshot') {
     
-    getActiveScreenshot();
+    getActiveScreenshot(id, command['args'] ? command['args']['window_id'] : null);
   }
 
   else if (command['name'] == 'get_words') {
     
-    getWords(command['tab_id'], command['match_regex'], command['join_with']);
+    getWords(id, command['args']['tab_id'], command['args']['match_regex'], command['args']['join_with']);
   }
 
   else if (command['name'] == 'get_text') {
     
-    getText(command['delimiter_regex'], command['replace_with']);
+    getText(id, command['args']['delimiter_regex'], command['args']['replace_with']);
   }
 
   else if (command['name'] == 'get_html') {
     
-    getHtml(command['delimiter_regex'], command['replace_with']);
+    getHtml(id, command['args']['delimiter_regex'], command['args']['replace_with']);
   }
 
   else if (command['name'] == 'get_browser') {
-    getBrowserName();
+    getBrowserName(id);
   } else {
     
   }
@@ -829,6 +883,88 @@ function handleDisconnect() {
 }
 
 
+// ============================================================================
+// EVENT STREAM
+// ============================================================================
+
+/**
+ * Push an unsolicited tab/window event to the mediator
+ */
+function sendEvent(event, data) {
+  if (!port) {
+    return;
+  }
+
+  try {
+    port.postMessage(Object.assign({type: 'event', event: event}, data));
+  } catch (error) {
+    // Port went away; the event is simply lost
+  }
+}
+
+function tabEventData(tab) {
+  return {
+    tab_id: `f.${tab.windowId}.${tab.id}`,
+    window_id: tab.windowId,
+    index: tab.index,
+    title: tab.title,
+    url: tab.url,
+    active: tab.active,
+    pinned: tab.pinned
+  };
+}
+
+browser.tabs.onCreated.addListener((tab) => {
+  sendEvent('tab_created', tabEventData(tab));
+});
+
+browser.tabs.onRemoved.addListener((tabId, removeInfo) => {
+  sendEvent('tab_removed', {
+    tab_id: `f.${removeInfo.windowId}.${tabId}`,
+    window_id: removeInfo.windowId
+  });
+});
+
+browser.tabs.onUpdated.addListener((tabId, changeInfo, tab) => {
+  // Only report changes that affect what tabctl shows
+  if (changeInfo.title === undefined && changeInfo.url === undefined &&
+      changeInfo.pinned === undefined && changeInfo.status !== 'complete') {
+    return;
+  }
+  sendEvent('tab_updated', tabEventData(tab));
+});
+
+browser.tabs.onActivated.addListener((activeInfo) => {
+  sendEvent('tab_activated', {
+    tab_id: `f.${activeInfo.windowId}.${activeInfo.tabId}`,
+    window_id: activeInfo.windowId
+  });
+});
+
+browser.tabs.onMoved.addListener((tabId, moveInfo) => {
+  sendEvent('tab_moved', {
+    tab_id: `f.${moveInfo.windowId}.${tabId}`,
+    window_id: moveInfo.windowId,
+    from_index: moveInfo.fromIndex,
+    to_index: moveInfo.toIndex
+  });
+});
+
+browser.tabs.onAttached.addListener((tabId, attachInfo) => {
+  // A tab dragged into another window is reported as a move with no origin index
+  sendEvent('tab_moved', {
+    tab_id: `f.${attachInfo.newWindowId}.${tabId}`,
+    window_id: attachInfo.newWindowId,
+    from_index: -1,
+    to_index: attachInfo.newPosition
+  });
+});
+
+browser.windows.onFocusChanged.addListener((windowId) => {
+  // windowId is WINDOW_ID_NONE (-1) when every browser window lost focus
+  sendEvent('window_focused', {window_id: windowId});
+});
+
 /*
 On a click on the browser action, send the app a message.
 */
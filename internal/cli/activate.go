@@ -4,38 +4,67 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/pkg/types"
 )
 
 var (
 	activateFocused bool
+	activateFilter  string
 )
 
 var activateCmd = &cobra.Command{
 	Use:   "activate <tab_id>",
 	Short: "Activate given tab ID",
 	Long: `Activate given tab ID. Tab ID should be in the following format:
-"<prefix>.<window_id>.<tab_id>"`,
-	Args: cobra.ExactArgs(1),
+"<prefix>.<window_id>.<tab_id>"
+
+With --filter, activates the first tab matching the expression instead (see
+"tabctl list --help" for the syntax).
+
+Prints the outcome like "tabctl close": tab ID, status and error.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if activateFilter != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runActivateTab(args[0], activateFocused)
+		// Past argument checks, errors are not about usage
+		cmd.SilenceUsage = true
+		tabID := ""
+		if len(args) > 0 {
+			tabID = args[0]
+		}
+		return runActivateTab(tabID, activateFocused)
 	},
 }
 
 func init() {
 	activateCmd.Flags().BoolVar(&activateFocused, "focused", false, "make browser focused after tab activation")
+	activateCmd.Flags().StringVar(&activateFilter, "filter", "", filterHelp)
 }
 
 func runActivateTab(tabID string, focused bool) error {
 	// Create browser manager
-	bm := client.NewBrowserManager(targetBrowser)
-	defer bm.Close()
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	if activateFilter != "" {
+		tabIDs, err := filteredTabIDs(bm, activateFilter)
+		if err != nil {
+			return err
+		}
+		if len(tabIDs) == 0 {
+			return fmt.Errorf("no tab matches the filter")
+		}
+		tabID = tabIDs[0]
+	}
 
 	// Activate the tab
-	if err := bm.ActivateTab(tabID); err != nil {
+	result, err := bm.ActivateTab(tabID)
+	if err != nil {
 		return fmt.Errorf("failed to activate tab: %w", err)
 	}
 
-	fmt.Printf("Activated tab %s\n", tabID)
-	return nil
+	return reportTabResults("activate", []types.TabResult{result}, "")
 }
\ No newline at end of file
@@ -4,24 +4,54 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/tabctl/tabctl/internal/client"
 	"github.com/tabctl/tabctl/internal/utils"
 )
 
+var (
+	closeFilter string
+)
+
 var closeCmd = &cobra.Command{
 	Use:   "close [tab_ids...]",
 	Short: "Close specified tab IDs",
 	Long: `Close specified tab IDs. Tab IDs should be in the following format:
 "<prefix>.<window_id>.<tab_id>". You can use "list" command to obtain
-tab IDs (first column). If no tab IDs are provided, reads from stdin.`,
+tab IDs (first column). If no tab IDs are provided, reads from stdin.
+
+With --filter, closes every tab matching the expression instead (see
+"tabctl list --help" for the syntax).
+
+Prints the outcome for each tab: its ID, a status (ok, not_found,
+unavailable when its browser is not connected or does not answer, or error)
+and the error. --format simple prints only the IDs of the closed tabs.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// Failures are reported per tab, not as usage errors
+		cmd.SilenceUsage = true
 		return runCloseTabs(args)
 	},
 }
 
+func init() {
+	closeCmd.Flags().StringVar(&closeFilter, "filter", "", filterHelp)
+}
+
 func runCloseTabs(tabIDs []string) error {
-	// Read from stdin if no args provided
-	if len(tabIDs) == 0 {
+	if closeFilter != "" && len(tabIDs) > 0 {
+		return fmt.Errorf("tab IDs and --filter cannot be combined")
+	}
+
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	// Select tabs by filter, or read from stdin if no args provided
+	if closeFilter != "" {
+		var err error
+		tabIDs, err = filteredTabIDs(bm, closeFilter)
+		if err != nil {
+			return err
+		}
+	} else if len(tabIDs) == 0 {
 		lines, err := utils.ReadStdinLines()
 		if err != nil {
 			return fmt.Errorf("failed to read from stdin: %w", err)
@@ -30,19 +60,14 @@ func runCloseTabs(tabIDs []string) error {
 	}
 
 	if len(tabIDs) == 0 {
-		fmt.Println("No tabs to close")
-		return nil
+		return reportTabResults("close", nil, "No tabs to close")
 	}
 
-	// Create browser manager
-	bm := client.NewBrowserManager(targetBrowser)
-	defer bm.Close()
-
 	// Close tabs
-	if err := bm.CloseTabs(tabIDs); err != nil {
+	results, err := bm.CloseTabs(tabIDs)
+	if err != nil {
 		return fmt.Errorf("failed to close tabs: %w", err)
 	}
 
-	fmt.Printf("Closed %d tab(s)\n", len(tabIDs))
-	return nil
+	return reportTabResults("close", results, "")
 }
\ No newline at end of file
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change settings",
+	Long: `Show and change the settings in the config file, a YAML file at
+$XDG_CONFIG_HOME/tabctl/` + config.FileName + ` ("tabctl config path" prints it):
+
+  format: json
+  timeout: 10s
+  default_browser: work
+  prefixes:
+    Brave: b
+  aliases:
+    work: Firefox:work
+  filters:
+    gh: domain:github.com and not pinned
+  mediator:
+    log_file: /tmp/tabctl-mediator-{pid}.log
+
+Every setting can also be given in the environment as TABCTL_ followed by
+its name in capitals ("mediator.log_file" is TABCTL_MEDIATOR_LOG_FILE);
+sections take "name=value" pairs separated by commas, as in
+TABCTL_PREFIXES="Brave=b,Zen=z". Command line flags override the
+environment, which overrides the file.
+
+Settings:
+` + settingsHelp(),
+	// Settings are read by the subcommands themselves, so a broken config
+	// file can still be inspected and fixed
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return nil
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show every setting with its value and source",
+	Long: `Show every setting with its effective value and where the value comes
+from: default, file or env.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigShow()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting",
+	Example: `  tabctl config get timeout
+  tabctl config get prefixes.Brave`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigGet(args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in the config file",
+	Long: `Change a setting in the config file, creating the file if needed. An
+empty value removes the setting. Comments in the file are kept.`,
+	Example: `  tabctl config set format json
+  tabctl config set aliases.work Firefox:work
+  tabctl config set filters.gh 'domain:github.com and not pinned'
+  tabctl config set timeout ""`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigSet(args[0], args[1])
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := config.Path()
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configPathCmd)
+}
+
+// settingsHelp lists the settings and sections with their descriptions
+func settingsHelp() string {
+	var b strings.Builder
+	for _, key := range append(append([]config.Key(nil), config.Keys...), config.Sections...) {
+		name := key.Name
+		if key.Kind == "" {
+			name += ".<name>"
+		}
+		fmt.Fprintf(&b, "  %-20s %s\n", name, key.Help)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func runConfigShow() error {
+	settings, err := config.Load()
+	if err != nil {
+		return err
+	}
+	entries := settings.Entries()
+
+	enc, err := newEncoder("key", "value", "source")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		// csv quotes values itself
+		value := entry.Value
+		if outputFormat != "csv" {
+			value = displayValue(value)
+		}
+		err := enc.Encode(record{
+			Value:  entry,
+			Fields: []string{entry.Key, value, entry.Source},
+			Line:   entry.Key + "=" + value,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// displayValue quotes values holding control characters, such as the tab
+// delimiter, so they stay visible
+func displayValue(value string) string {
+	if strings.ContainsFunc(value, func(r rune) bool { return r < ' ' }) {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+func runConfigGet(key string) error {
+	if err := config.ValidateKey(key); err != nil {
+		return err
+	}
+	settings, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	entry, ok := settings.Get(key)
+	if !ok {
+		return fmt.Errorf("%s is not set", key)
+	}
+	fmt.Println(entry.Value)
+	return nil
+}
+
+func runConfigSet(key, value string) error {
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	if err := config.Set(path, key, value); err != nil {
+		return err
+	}
+
+	if value == "" {
+		fmt.Printf("Removed %s from %s\n", key, path)
+	} else {
+		fmt.Printf("Set %s in %s\n", key, path)
+	}
+	if env := config.EnvName(key); os.Getenv(env) != "" {
+		fmt.Printf("Note: $%s overrides the file\n", env)
+	}
+	return nil
+}
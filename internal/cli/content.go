@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	contentDelimiterRegex string
+	contentReplaceWith    string
+	contentCleanup        bool
+)
+
+var textCmd = &cobra.Command{
+	Use:   "text [tab_ids...]",
+	Short: "Show text of tabs",
+	Long: `Show the page text of the given tab IDs, or of every loaded tab if none
+are given. Output columns are tab ID, title, URL and text.
+
+Line breaks and tabs in the text are replaced with spaces so each tab stays
+on one line; use --delimiter-regex and --replace-with to change that.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGetContent(args, false)
+	},
+}
+
+var htmlCmd = &cobra.Command{
+	Use:   "html [tab_ids...]",
+	Short: "Show HTML of tabs",
+	Long: `Show the page HTML of the given tab IDs, or of every loaded tab if none
+are given. Output columns are tab ID, title, URL and HTML.
+
+Line breaks and tabs in the HTML are replaced with spaces so each tab stays
+on one line; use --delimiter-regex and --replace-with to change that.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGetContent(args, true)
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{textCmd, htmlCmd} {
+		cmd.Flags().StringVar(&contentDelimiterRegex, "delimiter-regex", "", `JavaScript regular expression to replace in the content (default "\n|\r|\t")`)
+		cmd.Flags().StringVar(&contentReplaceWith, "replace-with", "", `replacement for --delimiter-regex matches (default " ")`)
+		cmd.Flags().BoolVar(&contentCleanup, "cleanup", false, "collapse runs of whitespace")
+	}
+}
+
+func runGetContent(tabIDs []string, html bool) error {
+	options := types.TextOptions{
+		DelimiterRegex: contentDelimiterRegex,
+		ReplaceWith:    contentReplaceWith,
+		Cleanup:        contentCleanup,
+	}
+
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	var contents []types.TabContent
+	var err error
+	if html {
+		contents, err = bm.GetHTML(tabIDs, options)
+	} else {
+		contents, err = bm.GetText(tabIDs, options)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get tab content: %w", err)
+	}
+
+	return FormatContentList(contents)
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/config"
+	"github.com/tabctl/tabctl/internal/daemon"
+	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/internal/session"
+)
+
+var (
+	snapshotInterval time.Duration
+	historyKeep      int
+	historyMaxAge    time.Duration
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Serve the manager D-Bus service for all browsers",
+	Long: `Own the ` + dbus.ServiceNameBase + ` name and serve the ` + dbus.InterfaceManager + `
+interface at ` + string(dbus.ObjectPath("")) + ` until interrupted.
+
+The manager aggregates every connected browser behind one endpoint, for
+panels, launchers and other tools:
+
+  ListBrowsers() -> as          names of the connected browsers
+  ListAllTabs()  -> a(sssibb)   tabs of every browser
+  ActivateTab(s) -> b           activate a tab, routed by its ID prefix
+
+Browsers that connect or disconnect are tracked automatically. Tab IDs use
+the same prefixes as the other tabctl commands.
+
+The daemon also snapshots the open tabs every --snapshot-interval and on
+shutdown into the "` + session.HistoryDirName + `" directory of the tabctl cache directory.
+A snapshot is skipped when nothing changed since the last one. See
+"tabctl history" to list and restore them.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDaemon()
+	},
+}
+
+func init() {
+	daemonCmd.Flags().DurationVar(&snapshotInterval, "snapshot-interval", config.SnapshotInterval, "Time between session snapshots (0 = no snapshots)")
+	daemonCmd.Flags().IntVar(&historyKeep, "history-keep", config.HistoryKeep, "Maximum number of snapshots to keep (0 = no limit)")
+	daemonCmd.Flags().DurationVar(&historyMaxAge, "history-max-age", config.HistoryMaxAge, "Delete snapshots older than this (0 = no limit)")
+}
+
+func runDaemon() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := daemon.New(callTimeout)
+	if snapshotInterval > 0 {
+		history, err := session.DefaultHistory(historyKeep, historyMaxAge)
+		if err != nil {
+			return err
+		}
+		d.SetSnapshots(history, snapshotInterval)
+	}
+
+	if err := d.Run(ctx); err != nil {
+		return fmt.Errorf("daemon failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	dedupeDryRun bool
+)
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Close duplicate tabs",
+	Long: `Find tabs showing the same page across all windows and browsers and close
+all but one of each group.
+
+URLs are compared without their fragment, tracking parameters (utm_*,
+fbclid, gclid, ...) and trailing slashes. The tab kept is a pinned one if
+any, else the active one, else the leftmost.
+
+Output lists every group, one tab per line: "keep" or "close", tab ID, title
+and URL. --format json and yaml print a report with the groups instead, and
+--format simple only the IDs of the duplicates. Use --dry-run to see what
+would be closed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDedupe()
+	},
+}
+
+func init() {
+	dedupeCmd.Flags().BoolVar(&dedupeDryRun, "dry-run", false, "only report the duplicates, do not close them")
+}
+
+// dedupeReport is the JSON output of dedupe
+type dedupeReport struct {
+	DryRun     bool                   `json:"dry_run"`
+	Duplicates int                    `json:"duplicates"`
+	Closed     int                    `json:"closed"`
+	Groups     []types.DuplicateGroup `json:"groups"`
+}
+
+func runDedupe() error {
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	groups, closeErr := bm.RemoveDuplicates(dedupeDryRun)
+	if groups == nil && closeErr != nil {
+		return fmt.Errorf("failed to find duplicate tabs: %w", closeErr)
+	}
+
+	closed := !dedupeDryRun && closeErr == nil
+	if err := formatDuplicates(groups, closed); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// duplicateRecord is the ndjson, csv and tsv form of a tab of a
+// duplicate group
+type duplicateRecord struct {
+	Action string `json:"action"` // "keep" or "close"
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+}
+
+// formatDuplicates outputs the duplicate groups one tab per record, with a
+// report for json and yaml and only the duplicate IDs for simple. closed
+// tells whether the duplicates were closed.
+func formatDuplicates(groups []types.DuplicateGroup, closed bool) error {
+	duplicates := 0
+	for _, group := range groups {
+		duplicates += len(group.Close)
+	}
+	closedCount := 0
+	if closed {
+		closedCount = duplicates
+	}
+
+	enc, err := newEncoder("action", "id", "title", "url")
+	if err != nil {
+		return err
+	}
+	if groups == nil {
+		groups = []types.DuplicateGroup{}
+	}
+	enc.Document = dedupeReport{
+		DryRun:     dedupeDryRun,
+		Duplicates: duplicates,
+		Closed:     closedCount,
+		Groups:     groups,
+	}
+
+	for _, group := range groups {
+		// simple lists only the tabs to close
+		if outputFormat != "simple" {
+			if err := enc.Encode(duplicateTabRecord("keep", group.Keep)); err != nil {
+				return err
+			}
+		}
+		for _, tab := range group.Close {
+			if err := enc.Encode(duplicateTabRecord("close", tab)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	// The summary goes to stderr so the TSV rows stay parseable
+	if enc.format == "tsv" {
+		if closed {
+			fmt.Fprintf(os.Stderr, "Closed %d duplicate tab(s)\n", closedCount)
+		} else {
+			fmt.Fprintf(os.Stderr, "Found %d duplicate tab(s)\n", duplicates)
+		}
+	}
+	return nil
+}
+
+func duplicateTabRecord(action string, tab types.Tab) record {
+	return record{
+		Value:  duplicateRecord{Action: action, ID: tab.ID, Title: tab.Title, URL: tab.URL},
+		Fields: []string{action, tab.ID, tab.Title, tab.URL},
+		Line:   tab.ID,
+	}
+}
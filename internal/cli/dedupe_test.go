@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// The TSV rows of dedupe are the only thing on stdout, so they can be piped
+func TestFormatDuplicatesTSV(t *testing.T) {
+	setFlags(t, map[string]string{"format": "tsv"})
+	setTerminal(t, false)
+
+	groups := []types.DuplicateGroup{{
+		Keep:  types.Tab{ID: "f.1.1", Title: "one", URL: "https://one.example"},
+		Close: []types.Tab{{ID: "f.1.2", Title: "one", URL: "https://one.example/"}},
+	}}
+	got := captureStdout(t, func() error { return formatDuplicates(groups, true) })
+
+	want := "keep\tf.1.1\tone\thttps://one.example\n" +
+		"close\tf.1.2\tone\thttps://one.example/\n"
+	if got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/filter"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// filterHelp documents --filter for the commands that accept it
+const filterHelp = `select tabs by expression, e.g. 'domain:github.com and not pinned', or @name for a named filter (see "tabctl list --help")`
+
+// parseFilter compiles a filter expression, or the named filter "@name"
+// from the settings
+func parseFilter(expr string) (filter.Predicate, error) {
+	expr, err := resolveFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	return filter.Parse(expr)
+}
+
+// filteredTabs lists the tabs of every browser in bm matching the filter
+// expression
+func filteredTabs(bm *client.BrowserManager, expr string) ([]types.Tab, error) {
+	pred, err := parseFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	tabs, err := bm.ListAllTabs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+
+	return filter.Tabs(tabs, pred), nil
+}
+
+// filteredTabIDs returns the IDs of the tabs matching the filter expression
+func filteredTabIDs(bm *client.BrowserManager, expr string) ([]string, error) {
+	tabs, err := filteredTabs(bm, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	tabIDs := make([]string, len(tabs))
+	for i, tab := range tabs {
+		tabIDs[i] = tab.ID
+	}
+	return tabIDs, nil
+}
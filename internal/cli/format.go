@@ -1,135 +1,633 @@
 package cli
 
 import (
+	"bufio"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"text/template"
+	"unicode/utf8"
 
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/utils"
 	"github.com/tabctl/tabctl/pkg/types"
+	"gopkg.in/yaml.v3"
 )
 
-// FormatOutput formats tabs based on the global output format flag
-func FormatOutput(tabs []types.Tab) error {
-	switch outputFormat {
+// tabRow is a tab as seen by --template, --columns and rofi rows
+type tabRow struct {
+	ID       string
+	Title    string
+	URL      string
+	Domain   string // host without "www."
+	Browser  string // mediator name, e.g. "Firefox:work"
+	Prefix   string // tab ID prefix without the dot
+	Window   string // window ID with its prefix, e.g. "f.1"
+	WindowID int
+	Index    int
+	Active   bool
+	Pinned   bool
+	Audible  bool
+	Muted    bool
+}
+
+// newTabRow fills a row from a tab; browsers maps prefixes ("f.") to
+// browser names
+func newTabRow(tab types.Tab, browsers map[string]string) tabRow {
+	prefix := utils.GetTabPrefix(tab.ID)
+	return tabRow{
+		ID:       tab.ID,
+		Title:    tab.Title,
+		URL:      tab.URL,
+		Domain:   domain(tab.URL),
+		Browser:  browsers[prefix],
+		Prefix:   strings.TrimSuffix(prefix, "."),
+		Window:   prefix + strconv.Itoa(tab.WindowID),
+		WindowID: tab.WindowID,
+		Index:    tab.Index,
+		Active:   tab.Active,
+		Pinned:   tab.Pinned,
+		Audible:  tab.Audible,
+		Muted:    tab.Muted,
+	}
+}
+
+// tabBrowsers maps the tab ID prefixes of the browsers in bm to their names
+func tabBrowsers(bm *client.BrowserManager) map[string]string {
+	browsers := make(map[string]string)
+	for _, c := range bm.GetClients() {
+		browsers[c.GetPrefix()] = c.GetBrowser()
+	}
+	return browsers
+}
+
+// tabColumn is a column available to --columns
+type tabColumn struct {
+	name  string
+	value func(row tabRow) string
+}
+
+// tabColumns are the columns of --columns, in the order --help lists them
+var tabColumns = []tabColumn{
+	{"id", func(row tabRow) string { return row.ID }},
+	{"prefix", func(row tabRow) string { return row.Prefix }},
+	{"browser", func(row tabRow) string { return row.Browser }},
+	{"window", func(row tabRow) string { return row.Window }},
+	{"index", func(row tabRow) string { return strconv.Itoa(row.Index) }},
+	{"active", func(row tabRow) string { return strconv.FormatBool(row.Active) }},
+	{"pinned", func(row tabRow) string { return strconv.FormatBool(row.Pinned) }},
+	{"audible", func(row tabRow) string { return strconv.FormatBool(row.Audible) }},
+	{"muted", func(row tabRow) string { return strconv.FormatBool(row.Muted) }},
+	{"title", func(row tabRow) string { return row.Title }},
+	{"url", func(row tabRow) string { return row.URL }},
+	{"domain", func(row tabRow) string { return row.Domain }},
+}
+
+// defaultColumns are the TSV columns without --columns
+var defaultColumns = []string{"id", "title", "url"}
+
+func tabColumnNames() []string {
+	names := make([]string, len(tabColumns))
+	for i, column := range tabColumns {
+		names[i] = column.name
+	}
+	return names
+}
+
+// selectedColumns resolves --columns, defaulting to defaultColumns
+func selectedColumns() ([]tabColumn, error) {
+	names := outputColumns
+	if len(names) == 0 {
+		names = defaultColumns
+	}
+
+	columns := make([]tabColumn, 0, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, column := range tabColumns {
+			if column.name == name {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown column %q (known: %s)", name, strings.Join(tabColumnNames(), ", "))
+		}
+	}
+	return columns, nil
+}
+
+// showHeaders reports whether TSV output starts with a header row. Headers
+// are shown on a terminal so pipes keep getting bare rows; --no-headers
+// (or --no-headers=false) decides otherwise.
+func showHeaders() bool {
+	if rootCmd.PersistentFlags().Changed("no-headers") {
+		return !noHeaders
+	}
+	return stdoutIsTerminal()
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal
+var stdoutIsTerminal = func() bool {
+	return utils.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// templateHelp documents the fields and functions of tab templates
+const templateHelp = `Fields: .ID, .Title, .URL, .Domain, .Browser, .Prefix, .Window, .WindowID,
+.Index, .Active, .Pinned, .Audible, .Muted. Functions: domain URL,
+truncate N TEXT (or trunc), pad N TEXT (left-aligned; negative N pads on the
+left), escape TEXT (writes tabs and newlines as \t and \n).`
+
+// templateFuncs are the helper functions available to --template and rofi
+// row templates
+var templateFuncs = template.FuncMap{
+	"domain":   domain,
+	"truncate": truncate,
+	"trunc":    truncate,
+	"pad":      pad,
+	"escape":   escape,
+}
+
+// parseTemplate parses a row template with the helper functions
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// domain returns the host of a URL without "www."
+func domain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// truncate shortens s to n characters, ending with "…" if it was cut
+func truncate(n int, s string) string {
+	runes := []rune(s)
+	if n <= 0 || len(runes) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// pad pads s with spaces to n characters, on the left if n is negative
+func pad(n int, s string) string {
+	width := utf8.RuneCountInString(s)
+	if n < 0 {
+		if -n > width {
+			return strings.Repeat(" ", -n-width) + s
+		}
+		return s
+	}
+	if n > width {
+		return s + strings.Repeat(" ", n-width)
+	}
+	return s
+}
+
+// escape writes backslashes, tabs, newlines and carriage returns as escape
+// sequences so a value stays on one line and in one field
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// record is one item of output: Value is written by json, ndjson and yaml,
+// Fields (in header order) by tsv and csv, and Line by simple
+type record struct {
+	Value  any
+	Fields []string
+	Line   string
+}
+
+// encoder writes records in the output format. json and yaml collect the
+// values and write them as one list on Close; the other formats write each
+// record as it comes.
+type encoder struct {
+	format string
+	header []string
+	stream bool
+	out    *bufio.Writer
+	csv    *csv.Writer
+	values []any
+	count  int
+
+	// Document, if set, is written by json and yaml instead of the list of
+	// record values, for reports with fields of their own
+	Document any
+	// Empty is printed by tsv and simple when there were no records
+	Empty string
+}
+
+// newEncoder returns an encoder for --format writing to stdout: tsv, csv,
+// json, ndjson, yaml or simple, with any other format falling back to tsv.
+// header names the fields of the records; tsv prints it in capitals when
+// headers are shown, csv unless --no-headers is set.
+func newEncoder(header ...string) (*encoder, error) {
+	e := &encoder{
+		format: outputFormat,
+		header: header,
+		out:    bufio.NewWriter(os.Stdout),
+	}
+
+	switch e.format {
+	case "json", "ndjson", "yaml", "simple":
+	case "csv":
+		e.csv = csv.NewWriter(e.out)
+		if rootCmd.PersistentFlags().Changed("delimiter") {
+			comma, size := utf8.DecodeRuneInString(delimiter)
+			if size == 0 || size != len(delimiter) {
+				return nil, fmt.Errorf("--delimiter must be a single character for csv")
+			}
+			e.csv.Comma = comma
+		}
+	default:
+		e.format = "tsv"
+	}
+	return e, nil
+}
+
+// newStreamEncoder returns an encoder for output that never ends, such as
+// events: every record is written at once, json as ndjson and yaml as a
+// stream of documents
+func newStreamEncoder(header ...string) (*encoder, error) {
+	e, err := newEncoder(header...)
+	if err != nil {
+		return nil, err
+	}
+	e.stream = true
+	if e.format == "json" {
+		e.format = "ndjson"
+	}
+	return e, nil
+}
+
+// Encode writes a record, or keeps its value for Close
+func (e *encoder) Encode(r record) error {
+	if e.count == 0 {
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+	}
+	e.count++
+
+	switch e.format {
 	case "json":
-		return formatJSON(tabs)
+		e.values = append(e.values, r.Value)
+		return nil
+	case "yaml":
+		if !e.stream {
+			e.values = append(e.values, r.Value)
+			return nil
+		}
+		node, err := yamlNode(r.Value)
+		if err != nil {
+			return err
+		}
+		if e.count > 1 {
+			e.out.WriteString("---\n")
+		}
+		if err := writeYAML(e.out, node); err != nil {
+			return err
+		}
+	case "ndjson":
+		data, err := json.Marshal(r.Value)
+		if err != nil {
+			return err
+		}
+		e.out.Write(data)
+		e.out.WriteString("\n")
+	case "csv":
+		if err := e.csv.Write(r.Fields); err != nil {
+			return err
+		}
 	case "simple":
-		return formatSimple(tabs)
-	case "tsv":
-		fallthrough
-	default:
-		return formatTSV(tabs)
+		e.out.WriteString(r.Line + "\n")
+	default: // tsv
+		e.out.WriteString(strings.Join(r.Fields, delimiter) + "\n")
+	}
+
+	if e.stream {
+		return e.flush()
 	}
+	return nil
 }
 
-// formatTSV outputs tabs in TSV format
-func formatTSV(tabs []types.Tab) error {
-	for _, tab := range tabs {
-		fmt.Printf("%s%s%s%s%s\n", tab.ID, delimiter, tab.Title, delimiter, tab.URL)
+// writeHeader writes the header row of tsv and csv
+func (e *encoder) writeHeader() error {
+	if len(e.header) == 0 {
+		return nil
+	}
+	switch e.format {
+	case "csv":
+		if !noHeaders {
+			return e.csv.Write(e.header)
+		}
+	case "tsv":
+		if showHeaders() {
+			fields := make([]string, len(e.header))
+			for i, name := range e.header {
+				fields[i] = strings.ToUpper(name)
+			}
+			e.out.WriteString(strings.Join(fields, delimiter) + "\n")
+		}
 	}
 	return nil
 }
 
-// formatJSON outputs tabs as JSON
-func formatJSON(tabs []types.Tab) error {
-	// Convert to a format with string IDs for compatibility
-	type JSONTab struct {
-		ID       string `json:"id"`
-		Title    string `json:"title"`
-		URL      string `json:"url"`
-		WindowID int    `json:"windowId"`
-		Index    int    `json:"index"`
-		Active   bool   `json:"active"`
-		Pinned   bool   `json:"pinned"`
+// Close writes the collected json and yaml values, or the Empty message,
+// and flushes the output
+func (e *encoder) Close() error {
+	var document any = e.values
+	if e.Document != nil {
+		document = e.Document
+	} else if e.values == nil {
+		document = []any{}
+	}
+
+	switch e.format {
+	case "json":
+		encoder := json.NewEncoder(e.out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	case "yaml":
+		if e.stream {
+			break
+		}
+		node, err := yamlNode(document)
+		if err != nil {
+			return err
+		}
+		if err := writeYAML(e.out, node); err != nil {
+			return err
+		}
+	case "csv":
+		if e.count == 0 {
+			if err := e.writeHeader(); err != nil {
+				return err
+			}
+		}
+	case "tsv", "simple":
+		if e.count == 0 && e.Empty != "" {
+			e.out.WriteString(e.Empty + "\n")
+		}
 	}
+	return e.flush()
+}
 
-	jsonTabs := make([]JSONTab, len(tabs))
-	for i, tab := range tabs {
-		jsonTabs[i] = JSONTab{
-			ID:       tab.ID,
-			Title:    tab.Title,
-			URL:      tab.URL,
-			WindowID: tab.WindowID,
-			Index:    tab.Index,
-			Active:   tab.Active,
-			Pinned:   tab.Pinned,
+func (e *encoder) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
 		}
 	}
+	return e.out.Flush()
+}
+
+// yamlNode converts a value to YAML through its JSON form, so YAML output
+// has the same keys, in the same order, as JSON output
+func yamlNode(v any) (*yaml.Node, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	clearYAMLStyle(&node)
+	return &node, nil
+}
+
+// clearYAMLStyle drops the flow style and quotes kept from JSON, leaving the
+// encoder to pick block style and quote only where needed
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+func writeYAML(w io.Writer, node *yaml.Node) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
 
-	encoder := json.NewEncoder(os.Stdout)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(jsonTabs)
+// jsonTab is the JSON form of a tab in tab lists
+type jsonTab struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	WindowID int    `json:"windowId"`
+	Index    int    `json:"index"`
+	Active   bool   `json:"active"`
+	Pinned   bool   `json:"pinned"`
 }
 
-// formatSimple outputs just tab IDs and titles (good for rofi)
-func formatSimple(tabs []types.Tab) error {
+// FormatOutput formats tabs based on the global output format flag: the
+// --columns of each tab for tsv and csv, its title for simple
+func FormatOutput(tabs []types.Tab, browsers map[string]string) error {
+	if outputFormat == "template" {
+		return formatTemplate(tabs, browsers)
+	}
+
+	columns, err := selectedColumns()
+	if err != nil {
+		return err
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+
+	enc, err := newEncoder(header...)
+	if err != nil {
+		return err
+	}
+	enc.Empty = "No tabs found"
 	for _, tab := range tabs {
-		// Simple format: just title for display, ID can be parsed by rofi
-		fmt.Printf("%s\n", tab.Title)
+		row := newTabRow(tab, browsers)
+		fields := make([]string, len(columns))
+		for i, column := range columns {
+			fields[i] = column.value(row)
+		}
+		err := enc.Encode(record{
+			Value: jsonTab{
+				ID:       tab.ID,
+				Title:    tab.Title,
+				URL:      tab.URL,
+				WindowID: tab.WindowID,
+				Index:    tab.Index,
+				Active:   tab.Active,
+				Pinned:   tab.Pinned,
+			},
+			Fields: fields,
+			Line:   tab.Title,
+		})
+		if err != nil {
+			return err
+		}
 	}
-	return nil
+	return enc.Close()
 }
 
-// FormatTabList formats a list of tabs with proper IDs (used by multiple commands)
-func FormatTabList(tabs []types.Tab) error {
-	if len(tabs) == 0 {
-		if outputFormat != "json" {
-			fmt.Println("No tabs found")
-		} else {
-			fmt.Println("[]")
+// formatTemplate executes --template once per tab, each on its own line
+func formatTemplate(tabs []types.Tab, browsers map[string]string) error {
+	if outputTemplate == "" {
+		return fmt.Errorf("--format template requires --template")
+	}
+	tmpl, err := parseTemplate("template", outputTemplate)
+	if err != nil {
+		return err
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for _, tab := range tabs {
+		if err := tmpl.Execute(out, newTabRow(tab, browsers)); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
 		}
-		return nil
+		out.WriteString("\n")
 	}
+	return nil
+}
 
-	return FormatOutput(tabs)
+// FormatTabList formats a list of tabs with proper IDs (used by multiple commands)
+func FormatTabList(tabs []types.Tab, browsers map[string]string) error {
+	return FormatOutput(tabs, browsers)
 }
 
 // FormatWindowList formats a list of windows
 func FormatWindowList(windows []types.Window) error {
-	switch outputFormat {
-	case "json":
-		encoder := json.NewEncoder(os.Stdout)
-		encoder.SetIndent("", "  ")
-		return encoder.Encode(windows)
-	case "simple":
-		for _, window := range windows {
-			fmt.Printf("Window %d (%d tabs)\n", window.ID, window.TabCount)
-		}
-	default: // tsv
-		for _, window := range windows {
-			fmt.Printf("%d%s%d\n", window.ID, delimiter, window.TabCount)
+	enc, err := newEncoder("id", "tabs")
+	if err != nil {
+		return err
+	}
+	for _, window := range windows {
+		err := enc.Encode(record{
+			Value:  window,
+			Fields: []string{strconv.Itoa(window.ID), strconv.Itoa(window.TabCount)},
+			Line:   fmt.Sprintf("Window %d (%d tabs)", window.ID, window.TabCount),
+		})
+		if err != nil {
+			return err
 		}
 	}
-	return nil
+	return enc.Close()
 }
 
 // FormatSingleValue formats a single value (like active tab ID)
 func FormatSingleValue(value string) error {
-	switch outputFormat {
-	case "json":
-		result := map[string]string{"value": value}
-		encoder := json.NewEncoder(os.Stdout)
-		return encoder.Encode(result)
-	default:
-		fmt.Println(value)
+	enc, err := newEncoder("value")
+	if err != nil {
+		return err
 	}
-	return nil
+	enc.Document = map[string]string{"value": value}
+	if err := enc.Encode(record{Value: enc.Document, Fields: []string{value}, Line: value}); err != nil {
+		return err
+	}
+	return enc.Close()
 }
 
-// FormatStringList formats a list of strings
-func FormatStringList(items []string) error {
-	switch outputFormat {
-	case "json":
-		encoder := json.NewEncoder(os.Stdout)
-		encoder.SetIndent("", "  ")
-		return encoder.Encode(items)
-	case "simple":
-		for _, item := range items {
-			fmt.Println(item)
+// FormatStringList formats a list of strings, one per record; name is the
+// header of the column. tsv keeps the items on a single line, joined by the
+// delimiter.
+func FormatStringList(name string, items []string) error {
+	enc, err := newEncoder(name)
+	if err != nil {
+		return err
+	}
+	if enc.format == "tsv" {
+		enc.out.WriteString(strings.Join(items, delimiter) + "\n")
+		return enc.Close()
+	}
+	for _, item := range items {
+		if err := enc.Encode(record{Value: item, Fields: []string{item}, Line: item}); err != nil {
+			return err
 		}
-	default: // tsv
-		fmt.Println(strings.Join(items, delimiter))
 	}
-	return nil
-}
\ No newline at end of file
+	return enc.Close()
+}
+
+// FormatContentList formats tab text or HTML content
+func FormatContentList(contents []types.TabContent) error {
+	enc, err := newEncoder("id", "title", "url", "content")
+	if err != nil {
+		return err
+	}
+	for _, content := range contents {
+		err := enc.Encode(record{
+			Value:  content,
+			Fields: []string{content.TabID, content.Title, content.URL, content.Content},
+			Line:   content.Content,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// reportTabResults outputs the outcome of an action per tab: tab ID,
+// status and error, or with simple only the IDs of the tabs it worked on.
+// empty is printed when there were no tabs to act on. If the action failed
+// for some or all tabs, the error carries ExitPartialFailure or ExitFailure.
+func reportTabResults(action string, results []types.TabResult, empty string) error {
+	enc, err := newEncoder("id", "status", "error")
+	if err != nil {
+		return err
+	}
+	enc.Empty = empty
+
+	failed := 0
+	for _, result := range results {
+		if result.Status != types.TabStatusOK {
+			failed++
+			if outputFormat == "simple" {
+				continue
+			}
+		}
+		err := enc.Encode(record{
+			Value:  result,
+			Fields: []string{result.TabID, result.Status, result.Error},
+			Line:   result.TabID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	switch {
+	case failed == 0:
+		return nil
+	case len(results) == 1:
+		return &exitError{ExitFailure, fmt.Errorf("failed to %s tab %s: %s", action, results[0].TabID, results[0].Error)}
+	case failed == len(results):
+		return &exitError{ExitFailure, fmt.Errorf("failed to %s all %d tab(s)", action, failed)}
+	}
+	return &exitError{ExitPartialFailure, fmt.Errorf("failed to %s %d of %d tab(s)", action, failed, len(results))}
+}
@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// setFlags sets global flags as if given on the command line, until the test
+// ends
+func setFlags(t *testing.T, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		flag := rootCmd.PersistentFlags().Lookup(name)
+		if flag == nil {
+			t.Fatalf("no flag --%s", name)
+		}
+		old, oldChanged := flag.Value.String(), flag.Changed
+		if err := flag.Value.Set(value); err != nil {
+			t.Fatal(err)
+		}
+		flag.Changed = true
+		t.Cleanup(func() {
+			flag.Value.Set(old)
+			flag.Changed = oldChanged
+		})
+	}
+}
+
+// setTerminal makes stdout look like a terminal, or not, until the test ends
+func setTerminal(t *testing.T, terminal bool) {
+	saved := stdoutIsTerminal
+	stdoutIsTerminal = func() bool { return terminal }
+	t.Cleanup(func() { stdoutIsTerminal = saved })
+}
+
+// captureStdout returns what fn writes to stdout
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	err = fn()
+	os.Stdout = saved
+	w.Close()
+
+	out, readErr := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return string(out)
+}
+
+// encode writes records with a new encoder for the current flags
+func encode(header []string, records []record, setup func(*encoder)) func() error {
+	return func() error {
+		enc, err := newEncoder(header...)
+		if err != nil {
+			return err
+		}
+		if setup != nil {
+			setup(enc)
+		}
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return enc.Close()
+	}
+}
+
+type testItem struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+func testRecords(items ...testItem) []record {
+	records := make([]record, len(items))
+	for i, item := range items {
+		records[i] = record{Value: item, Fields: []string{item.ID, item.Title}, Line: item.ID}
+	}
+	return records
+}
+
+func TestEncoderCSVQuoting(t *testing.T) {
+	setFlags(t, map[string]string{"format": "csv"})
+
+	records := testRecords(
+		testItem{"f.1.1", "plain"},
+		testItem{"f.1.2", "tab\there"},
+		testItem{"f.1.3", `say "hi"`},
+		testItem{"f.1.4", "two\nlines"},
+		testItem{"f.1.5", "a, b"},
+		testItem{"f.1.6", " padded "},
+	)
+	got := captureStdout(t, encode([]string{"id", "title"}, records, nil))
+
+	want := "id,title\n" +
+		"f.1.1,plain\n" +
+		"f.1.2,tab\there\n" +
+		"f.1.3,\"say \"\"hi\"\"\"\n" +
+		"f.1.4,\"two\nlines\"\n" +
+		"f.1.5,\"a, b\"\n" +
+		"f.1.6,\" padded \"\n"
+	if got != want {
+		t.Errorf("csv output =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestEncoderCSVDelimiter(t *testing.T) {
+	setFlags(t, map[string]string{"format": "csv", "delimiter": ";"})
+
+	got := captureStdout(t, encode([]string{"id", "title"}, testRecords(testItem{"f.1.1", "a;b\tc"}), nil))
+	if want := "id;title\nf.1.1;\"a;b\tc\"\n"; got != want {
+		t.Errorf("csv output = %q, want %q", got, want)
+	}
+
+	setFlags(t, map[string]string{"delimiter": "\t"})
+	got = captureStdout(t, encode([]string{"id", "title"}, testRecords(testItem{"f.1.1", "a\tb"}), nil))
+	if want := "id\ttitle\nf.1.1\t\"a\tb\"\n"; got != want {
+		t.Errorf("csv output = %q, want %q", got, want)
+	}
+
+	setFlags(t, map[string]string{"delimiter": "::"})
+	if _, err := newEncoder("id"); err == nil || !strings.Contains(err.Error(), "single character") {
+		t.Errorf("newEncoder with --delimiter :: error = %v, want a single character error", err)
+	}
+}
+
+func TestEncoderHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		flag     string // --no-headers value, "" if not given
+		terminal bool
+		want     string
+	}{
+		{"tsv on a terminal", "tsv", "", true, "ID\tTITLE\nf.1.1\tone\n"},
+		{"tsv in a pipe", "tsv", "", false, "f.1.1\tone\n"},
+		{"tsv --no-headers on a terminal", "tsv", "true", true, "f.1.1\tone\n"},
+		{"tsv --no-headers=false in a pipe", "tsv", "false", false, "ID\tTITLE\nf.1.1\tone\n"},
+		{"csv in a pipe", "csv", "", false, "id,title\nf.1.1,one\n"},
+		{"csv --no-headers", "csv", "true", true, "f.1.1,one\n"},
+		{"simple on a terminal", "simple", "", true, "f.1.1\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := map[string]string{"format": tt.format}
+			if tt.flag != "" {
+				flags["no-headers"] = tt.flag
+			}
+			setFlags(t, flags)
+			setTerminal(t, tt.terminal)
+
+			got := captureStdout(t, encode([]string{"id", "title"}, testRecords(testItem{"f.1.1", "one"}), nil))
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderEmpty(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"tsv", "Nothing here\n"},
+		{"simple", "Nothing here\n"},
+		{"csv", "id,title\n"},
+		{"json", "[]\n"},
+		{"yaml", "[]\n"},
+		{"ndjson", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setFlags(t, map[string]string{"format": tt.format})
+			setTerminal(t, false)
+
+			got := captureStdout(t, encode([]string{"id", "title"}, nil, func(enc *encoder) {
+				enc.Empty = "Nothing here"
+			}))
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderLists(t *testing.T) {
+	records := testRecords(testItem{"f.1.1", "one"}, testItem{"f.1.2", "two: 2"})
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", "[\n  {\n    \"id\": \"f.1.1\",\n    \"title\": \"one\"\n  },\n  {\n    \"id\": \"f.1.2\",\n    \"title\": \"two: 2\"\n  }\n]\n"},
+		{"ndjson", "{\"id\":\"f.1.1\",\"title\":\"one\"}\n{\"id\":\"f.1.2\",\"title\":\"two: 2\"}\n"},
+		{"yaml", "- id: f.1.1\n  title: one\n- id: f.1.2\n  title: 'two: 2'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setFlags(t, map[string]string{"format": tt.format})
+
+			got := captureStdout(t, encode([]string{"id", "title"}, records, nil))
+			if got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderDocument(t *testing.T) {
+	type report struct {
+		DryRun bool       `json:"dry_run"`
+		Items  []testItem `json:"items"`
+	}
+	doc := report{DryRun: true, Items: []testItem{{"f.1.2", "dup"}}}
+	records := testRecords(testItem{"f.1.1", "kept"}, testItem{"f.1.2", "dup"})
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", "{\n  \"dry_run\": true,\n  \"items\": [\n    {\n      \"id\": \"f.1.2\",\n      \"title\": \"dup\"\n    }\n  ]\n}\n"},
+		{"yaml", "dry_run: true\nitems:\n  - id: f.1.2\n    title: dup\n"},
+		// Formats writing records as they come ignore the document
+		{"tsv", "f.1.1\tkept\nf.1.2\tdup\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setFlags(t, map[string]string{"format": tt.format})
+			setTerminal(t, false)
+
+			got := captureStdout(t, encode([]string{"id", "title"}, records, func(enc *encoder) {
+				enc.Document = doc
+			}))
+			if got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamEncoder(t *testing.T) {
+	records := testRecords(testItem{"f.1.1", "one"}, testItem{"f.1.2", "two"})
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"json", "{\"id\":\"f.1.1\",\"title\":\"one\"}\n{\"id\":\"f.1.2\",\"title\":\"two\"}\n"},
+		{"yaml", "id: f.1.1\ntitle: one\n---\nid: f.1.2\ntitle: two\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setFlags(t, map[string]string{"format": tt.format})
+
+			got := captureStdout(t, func() error {
+				enc, err := newStreamEncoder("id", "title")
+				if err != nil {
+					return err
+				}
+				for _, r := range records {
+					if err := enc.Encode(r); err != nil {
+						return err
+					}
+				}
+				return enc.Close()
+			})
+			if got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderUnknownFormat(t *testing.T) {
+	setFlags(t, map[string]string{"format": "xml"})
+	setTerminal(t, false)
+
+	got := captureStdout(t, encode([]string{"id", "title"}, testRecords(testItem{"f.1.1", "one"}), nil))
+	if want := "f.1.1\tone\n"; got != want {
+		t.Errorf("output = %q, want tsv %q", got, want)
+	}
+}
+
+func TestFormatStringList(t *testing.T) {
+	tests := []struct {
+		format string
+		items  []string
+		want   string
+	}{
+		{"tsv", []string{"f.1.1", "f.1.2", "f.1.3"}, "f.1.1\tf.1.2\tf.1.3\n"},
+		{"tsv", nil, "\n"},
+		{"unknown", []string{"f.1.1", "f.1.2"}, "f.1.1\tf.1.2\n"},
+		{"simple", []string{"f.1.1", "f.1.2"}, "f.1.1\nf.1.2\n"},
+		{"csv", []string{"f.1.1", "f.1.2"}, "id\nf.1.1\nf.1.2\n"},
+		{"json", []string{"f.1.1", "f.1.2"}, "[\n  \"f.1.1\",\n  \"f.1.2\"\n]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			setFlags(t, map[string]string{"format": tt.format})
+			setTerminal(t, true)
+
+			got := captureStdout(t, func() error { return FormatStringList("id", tt.items) })
+			if got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
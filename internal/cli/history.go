@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/config"
+	"github.com/tabctl/tabctl/internal/session"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	historyAt string
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List and restore session snapshots taken by tabctl daemon",
+	Long: `List and restore the snapshots of the open tabs that tabctl daemon takes
+periodically and on shutdown. Snapshots are stored as JSON files in the
+"` + session.HistoryDirName + `" directory of the tabctl cache directory and are named after
+the time they were taken, in UTC.`,
+}
+
+var historySessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List snapshots, oldest first",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistorySessions()
+	},
+}
+
+var historyRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Reopen the tabs of a snapshot",
+	Long: `Reopen the latest snapshot taken at or before --at, or the latest snapshot
+if --at is not given, as tabctl session restore does.
+
+--at takes a local time such as 2026-10-15T14:00, "2026-10-15 14:00" or
+2026-10-15 (the end of that day), or an RFC 3339 time with a zone.`,
+	Example: `  tabctl history restore --at 2026-10-15T14:00`,
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistoryRestore()
+	},
+}
+
+func init() {
+	historyRestoreCmd.Flags().StringVar(&historyAt, "at", "", "restore the state at this time (default: latest snapshot)")
+
+	historyCmd.AddCommand(historySessionsCmd)
+	historyCmd.AddCommand(historyRestoreCmd)
+}
+
+// openHistory opens the history with the daemon's default limits; reading
+// never prunes, so the limits only matter to the daemon
+func openHistory() (*session.History, error) {
+	return session.DefaultHistory(config.HistoryKeep, config.HistoryMaxAge)
+}
+
+func runHistorySessions() error {
+	history, err := openHistory()
+	if err != nil {
+		return err
+	}
+	snapshots, err := history.List()
+	if err != nil {
+		return err
+	}
+
+	return formatSessionList(snapshots)
+}
+
+func runHistoryRestore() error {
+	history, err := openHistory()
+	if err != nil {
+		return err
+	}
+
+	var snapshot types.Session
+	if historyAt == "" {
+		snapshots, err := history.List()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			return fmt.Errorf("no snapshots found (is tabctl daemon running?)")
+		}
+		snapshot = snapshots[len(snapshots)-1]
+	} else {
+		at, err := parseHistoryTime(historyAt)
+		if err != nil {
+			return err
+		}
+		snapshot, err = history.At(at)
+		if err != nil {
+			return err
+		}
+	}
+
+	return restoreSession(snapshot)
+}
+
+// historyTimeLayouts are the local time forms accepted by --at
+var historyTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
+
+// parseHistoryTime parses --at. A date alone means the end of that day.
+func parseHistoryTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range historyTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use e.g. 2026-10-15T14:00", value)
+}
@@ -4,29 +4,69 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	listFilter string
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available tabs",
-	Long:  `List available tabs from all browsers connected via D-Bus.`,
+	Long: `List available tabs from all browsers connected via D-Bus.
+
+--filter keeps the tabs matching an expression. Terms are combined with
+and, or, not and parentheses:
+
+  pinned, active       tab flags
+  word, "some words"   title or URL contains the text; a word that starts
+                       with no field, such as https://x.org, is a word too
+  title:text url:text  field contains the text, ignoring case
+  domain:github.com    host is github.com or one of its subdomains
+  id= window= prefix= index=
+                       field equals the value (window:3 or window:f.3)
+  field~regex          field matches the regular expression
+
+"@name" uses the expression saved under that name in the filters section of
+the config file (see "tabctl config").
+
+--columns picks the TSV columns, with a header row when printing to a
+terminal (--no-headers drops it, --no-headers=false keeps it in pipes).
+--format template prints each tab with the Go template in --template.
+` + templateHelp,
+	Example: `  tabctl list --filter 'domain:github.com and not pinned and title~"PR #\d+"'
+  tabctl list --columns id,window,index,active,title,url
+  tabctl list --format template --template '{{.ID}} {{.Domain}} {{.Title | trunc 60}}'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runListTabs()
 	},
 }
 
+func init() {
+	listCmd.Flags().StringVar(&listFilter, "filter", "", filterHelp)
+}
+
 func runListTabs() error {
 	// Create browser manager to query browsers
-	bm := client.NewBrowserManager(targetBrowser)
-	defer bm.Close()
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
 
-	// List all tabs
-	tabs, err := bm.ListAllTabs()
-	if err != nil {
-		return fmt.Errorf("failed to list tabs: %w", err)
+	// List all tabs, or only the matching ones
+	var tabs []types.Tab
+	var err error
+	if listFilter != "" {
+		tabs, err = filteredTabs(bm, listFilter)
+		if err != nil {
+			return err
+		}
+	} else {
+		tabs, err = bm.ListAllTabs()
+		if err != nil {
+			return fmt.Errorf("failed to list tabs: %w", err)
+		}
 	}
 
 	// Use the format helper
-	return FormatTabList(tabs)
+	return FormatTabList(tabs, tabBrowsers(bm))
 }
\ No newline at end of file
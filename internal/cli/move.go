@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	moveWindow    string
+	moveIndex     int
+	moveNewWindow bool
+	moveFilter    string
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move [tab_ids...]",
+	Short: "Move tabs to another window or position",
+	Long: `Move tabs within a window or to another window of the same browser.
+Tab IDs should be in the following format: "<prefix>.<window_id>.<tab_id>".
+
+The target window is given with --window ("<prefix>.<window_id>" or just
+"<window_id>"), or --new-window to gather the tabs into a new window. Without
+either, tabs stay in their current window. Several tabs are placed one after
+another starting at --index; the default -1 appends them at the end.
+
+If no tab IDs are provided, reads moves from stdin, one per line:
+"<tab_id> <window_id> <index>". A line holding only a tab ID uses the flags.
+A window ID of 0 means a new window.
+
+With --filter, moves every tab matching the expression instead (see
+"tabctl list --help" for the syntax).
+
+Prints the outcome for each tab like "tabctl close": tab ID, status and
+error.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runMoveTabs(args)
+	},
+}
+
+func init() {
+	moveCmd.Flags().StringVar(&moveWindow, "window", "", "target window ID")
+	moveCmd.Flags().IntVar(&moveIndex, "index", -1, "target position within the window (-1 = end)")
+	moveCmd.Flags().BoolVar(&moveNewWindow, "new-window", false, "move the tabs into a new window")
+	moveCmd.Flags().StringVar(&moveFilter, "filter", "", filterHelp)
+	moveCmd.MarkFlagsMutuallyExclusive("window", "new-window")
+}
+
+func runMoveTabs(tabIDs []string) error {
+	if moveFilter != "" && len(tabIDs) > 0 {
+		return fmt.Errorf("tab IDs and --filter cannot be combined")
+	}
+
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	var moves []types.TabMove
+	var err error
+
+	switch {
+	case moveFilter != "":
+		tabIDs, err = filteredTabIDs(bm, moveFilter)
+		if err == nil {
+			moves, err = movesFromFlags(tabIDs)
+		}
+	case len(tabIDs) > 0:
+		moves, err = movesFromFlags(tabIDs)
+	default:
+		moves, err = movesFromStdin()
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(moves) == 0 {
+		return reportTabResults("move", nil, "No tabs to move")
+	}
+
+	results, err := bm.MoveTabs(moves)
+	if err != nil {
+		return fmt.Errorf("failed to move tabs: %w", err)
+	}
+
+	return reportTabResults("move", results, "")
+}
+
+// movesFromFlags builds moves for the given tabs from --window, --new-window
+// and --index, placing the tabs of each browser next to each other
+func movesFromFlags(tabIDs []string) ([]types.TabMove, error) {
+	nextIndex := make(map[string]int)
+	moves := make([]types.TabMove, 0, len(tabIDs))
+
+	for _, tabID := range tabIDs {
+		move, err := moveFromFlags(tabID)
+		if err != nil {
+			return nil, err
+		}
+
+		if moveIndex >= 0 {
+			prefix := utils.GetTabPrefix(tabID)
+			move.Index = moveIndex + nextIndex[prefix]
+			nextIndex[prefix]++
+		}
+		moves = append(moves, move)
+	}
+
+	return moves, nil
+}
+
+// moveFromFlags builds the move of a single tab from the command line flags
+func moveFromFlags(tabID string) (types.TabMove, error) {
+	if err := utils.ValidateTabID(tabID); err != nil {
+		return types.TabMove{}, err
+	}
+
+	move := types.TabMove{TabID: tabID, Index: moveIndex}
+	switch {
+	case moveNewWindow:
+		move.WindowID = 0
+	case moveWindow != "":
+		windowID, err := parseMoveWindow(tabID, moveWindow)
+		if err != nil {
+			return types.TabMove{}, err
+		}
+		move.WindowID = windowID
+	default:
+		windowID, err := strconv.Atoi(utils.GetWindowID(tabID))
+		if err != nil {
+			return types.TabMove{}, fmt.Errorf("invalid tab ID: %s", tabID)
+		}
+		move.WindowID = windowID
+	}
+
+	return move, nil
+}
+
+// movesFromStdin reads "<tab_id> <window_id> <index>" lines from stdin
+func movesFromStdin() ([]types.TabMove, error) {
+	lines, err := utils.ReadStdinLines()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read from stdin: %w", err)
+	}
+
+	var tabIDs []string
+	var moves []types.TabMove
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			tabIDs = append(tabIDs, fields[0])
+		case 3:
+			if err := utils.ValidateTabID(fields[0]); err != nil {
+				return nil, err
+			}
+			windowID, err := parseMoveWindow(fields[0], fields[1])
+			if err != nil {
+				return nil, err
+			}
+			index, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index in line %q", line)
+			}
+			moves = append(moves, types.TabMove{TabID: fields[0], WindowID: windowID, Index: index})
+		default:
+			return nil, fmt.Errorf("invalid move line %q: expected \"<tab_id> <window_id> <index>\"", line)
+		}
+	}
+
+	if len(tabIDs) > 0 {
+		flagMoves, err := movesFromFlags(tabIDs)
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, flagMoves...)
+	}
+
+	return moves, nil
+}
+
+// parseMoveWindow parses a target window given as "<prefix>.<window_id>" or
+// "<window_id>". Tabs can only move between windows of their own browser.
+func parseMoveWindow(tabID, window string) (int, error) {
+	windowStr := window
+	if strings.Contains(window, ".") {
+		prefix, id, err := utils.ParsePrefixAndWindowID(window)
+		if err != nil {
+			return 0, err
+		}
+		if prefix != utils.GetTabPrefix(tabID) {
+			return 0, fmt.Errorf("cannot move tab %s to window %s of another browser", tabID, window)
+		}
+		windowStr = id
+	}
+
+	windowID, err := strconv.Atoi(windowStr)
+	if err != nil || windowID < 0 {
+		return 0, fmt.Errorf("invalid window ID: %s", window)
+	}
+	return windowID, nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	openWindow     string
+	openNewWindow  bool
+	openBackground bool
+	openPinned     bool
+)
+
+var openCmd = &cobra.Command{
+	Use:   "open [urls...]",
+	Short: "Open URLs in new tabs",
+	Long: `Open URLs in new tabs and print their tab IDs, one per line.
+If no URLs are provided, reads them from stdin, one per line.
+
+Tabs open in the current window of the browser, in the window given with
+--window ("<prefix>.<window_id>"), or in a new window with --new-window.
+When several browsers are connected and neither --browser nor --window is
+given, the default_browser setting ($TABCTL_DEFAULT_BROWSER) is used if that
+browser is connected, otherwise the first browser by name.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runOpenURLs(args)
+	},
+}
+
+func init() {
+	openCmd.Flags().StringVar(&openWindow, "window", "", "open in this window (<prefix>.<window_id>)")
+	openCmd.Flags().BoolVar(&openNewWindow, "new-window", false, "open in a new window")
+	openCmd.Flags().BoolVar(&openBackground, "background", false, "do not switch to the new tabs")
+	openCmd.Flags().BoolVar(&openPinned, "pinned", false, "pin the new tabs")
+	openCmd.MarkFlagsMutuallyExclusive("window", "new-window")
+}
+
+func runOpenURLs(urls []string) error {
+	// Read from stdin if no args provided
+	if len(urls) == 0 {
+		lines, err := utils.ReadStdinLines()
+		if err != nil {
+			return fmt.Errorf("failed to read from stdin: %w", err)
+		}
+		urls = lines
+	}
+
+	if len(urls) == 0 {
+		fmt.Println("No URLs to open")
+		return nil
+	}
+
+	var prefix string
+	if openWindow != "" {
+		windowPrefix, windowID, err := utils.ParsePrefixAndWindowID(openWindow)
+		if err != nil {
+			return err
+		}
+		if windowID == "" {
+			return fmt.Errorf("invalid window ID: %s (expected <prefix>.<window_id>)", openWindow)
+		}
+		prefix = windowPrefix
+	}
+
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	browser, err := bm.SelectClient(prefix, defaultBrowser())
+	if err != nil {
+		return fmt.Errorf("failed to open URLs: %w", err)
+	}
+
+	windowID := openWindow
+	if openNewWindow {
+		windowID = browser.GetPrefix() + "0"
+	}
+
+	tabIDs, err := browser.OpenURLs(urls, windowID, types.OpenOptions{
+		Background: openBackground,
+		Pinned:     openPinned,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open URLs: %w", err)
+	}
+
+	return FormatStringList("id", tabIDs)
+}
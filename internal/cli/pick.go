@@ -0,0 +1,784 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/internal/filter"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	pickFilter string
+	pickPrint  bool
+)
+
+var pickCmd = &cobra.Command{
+	Use:     "pick",
+	Aliases: []string{"tui"},
+	Short:   "Pick tabs interactively with a fuzzy finder",
+	Long: `Open a full-screen fuzzy finder over the tabs of all browsers, grouped by
+browser and window. Typing filters the tabs by title and URL; the list
+follows tab changes in the browsers as they happen.
+
+Keys:
+  up/down, ctrl+p/ctrl+n   move the cursor
+  tab, shift+tab           mark or unmark the tab and move down/up
+  ctrl+a                   mark every matching tab
+  enter                    activate the tab and quit (--print: print IDs)
+  ctrl+x                   close the marked tabs, or the tab under the cursor
+  ctrl+t                   pin or unpin the marked tabs, or the tab under the cursor
+  ctrl+o                   move the marked tabs after the tab under the cursor
+  alt+o                    move the marked tabs into a new window
+  ctrl+y                   copy the URLs of the marked tabs, or the tab under the cursor
+  ctrl+r                   reload the tab list
+  esc, ctrl+c              quit
+
+With --filter, only tabs matching the expression are shown (see
+"tabctl list --help" for the syntax).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPick()
+	},
+}
+
+func init() {
+	pickCmd.Flags().StringVar(&pickFilter, "filter", "", filterHelp)
+	pickCmd.Flags().BoolVar(&pickPrint, "print", false, "print the IDs of the marked tabs (or the tab under the cursor) on enter instead of activating")
+}
+
+func runPick() error {
+	var pred filter.Predicate
+	if pickFilter != "" {
+		var err error
+		pred, err = parseFilter(pickFilter)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	bm := newBrowserManager()
+
+	// Live updates are best effort: without them the list still reloads
+	// after each action and on ctrl+r
+	var events <-chan dbus.Event
+	if watcher, err := dbus.NewPrivateClient(); err == nil {
+		defer watcher.Close()
+		events, _ = watcher.WatchEvents(ctx)
+	}
+
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "search title and URL"
+	input.Focus()
+
+	model := pickModel{
+		bm:     bm,
+		pred:   pred,
+		events: events,
+		input:  input,
+		marked: make(map[string]bool),
+	}
+
+	finalModel, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if final, ok := finalModel.(pickModel); ok {
+		// The picker replaces its manager when browsers come and go
+		defer final.bm.Close()
+	} else {
+		defer bm.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("error running tab picker: %w", err)
+	}
+
+	for _, tabID := range finalModel.(pickModel).picked {
+		fmt.Println(tabID)
+	}
+	return nil
+}
+
+// pickModel is the bubbletea model of tabctl pick
+type pickModel struct {
+	bm       *client.BrowserManager
+	browsers map[string]string // prefix -> browser name
+	pred     filter.Predicate  // --filter, nil for all tabs
+	events   <-chan dbus.Event
+
+	input   textinput.Model
+	tabs    []types.Tab // all tabs, by browser, window and index
+	visible []int       // indexes into tabs matching the query
+	marked  map[string]bool
+	cursor  int // index into visible
+	offset  int // first rendered line
+	width   int
+	height  int
+
+	loaded    bool
+	reloading bool // a reload is scheduled after a tab event
+	status    string
+	statusErr bool     // status is an error message
+	picked    []string // IDs to print on exit
+}
+
+// Messages of the tab picker
+type (
+	pickTabsMsg struct {
+		tabs     []types.Tab
+		browsers map[string]string
+		err      error
+	}
+	pickEventMsg struct {
+		event dbus.Event
+		ok    bool
+	}
+	pickReloadMsg  struct{}
+	pickManagerMsg struct{ bm *client.BrowserManager }
+	pickActionMsg  struct {
+		status string
+		err    error
+	}
+	pickActivateMsg struct{ err error }
+)
+
+// pickReloadDelay batches the events of one change (closing ten tabs
+// sends ten) into a single reload
+const pickReloadDelay = 200 * time.Millisecond
+
+var (
+	pickBrowserStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+	pickWindowStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	pickCursorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
+	pickMarkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	pickDimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("245"))
+	pickErrorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+// Init loads the tabs and starts listening for tab events
+func (m pickModel) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, m.load(), m.waitEvent())
+}
+
+// load lists the tabs of every browser in the background
+func (m pickModel) load() tea.Cmd {
+	bm := m.bm
+	return func() tea.Msg {
+		browsers := make(map[string]string)
+		for _, c := range bm.GetClients() {
+			browsers[c.GetPrefix()] = c.GetBrowser()
+		}
+
+		tabs, err := bm.ListAllTabs()
+		if err != nil {
+			return pickTabsMsg{browsers: browsers, err: err}
+		}
+
+		sort.SliceStable(tabs, func(i, j int) bool {
+			a, b := tabs[i], tabs[j]
+			if browserA, browserB := browsers[utils.GetTabPrefix(a.ID)], browsers[utils.GetTabPrefix(b.ID)]; browserA != browserB {
+				return browserA < browserB
+			}
+			if a.WindowID != b.WindowID {
+				return a.WindowID < b.WindowID
+			}
+			return a.Index < b.Index
+		})
+		return pickTabsMsg{tabs: tabs, browsers: browsers}
+	}
+}
+
+// rediscover builds a manager for the browsers now on the bus in the
+// background
+func rediscover() tea.Cmd {
+	return func() tea.Msg {
+		return pickManagerMsg{bm: newBrowserManager()}
+	}
+}
+
+// waitEvent waits for the next tab event
+func (m pickModel) waitEvent() tea.Cmd {
+	if m.events == nil {
+		return nil
+	}
+	events := m.events
+	return func() tea.Msg {
+		event, ok := <-events
+		return pickEventMsg{event: event, ok: ok}
+	}
+}
+
+// Update handles messages
+func (m pickModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = msg.Width - len(m.input.Prompt) - 1
+		m.scroll()
+		return m, nil
+
+	case pickTabsMsg:
+		m.loaded = true
+		m.browsers = msg.browsers
+		if msg.err != nil {
+			m.tabs = nil
+			m.setError(msg.err)
+		} else {
+			m.tabs = msg.tabs
+		}
+		m.applyQuery()
+		return m, nil
+
+	case pickEventMsg:
+		if !msg.ok {
+			m.events = nil
+			return m, nil
+		}
+		switch msg.event.Signal {
+		case dbus.SignalBrowserConnected, dbus.SignalBrowserDisconnected:
+			// Prefixes depend on the set of browsers; the tabs are reloaded
+			// once the new manager is ready
+			return m, tea.Batch(m.waitEvent(), rediscover())
+		}
+		cmds := []tea.Cmd{m.waitEvent()}
+		if !m.reloading {
+			m.reloading = true
+			cmds = append(cmds, tea.Tick(pickReloadDelay, func(time.Time) tea.Msg { return pickReloadMsg{} }))
+		}
+		return m, tea.Batch(cmds...)
+
+	case pickReloadMsg:
+		m.reloading = false
+		return m, m.load()
+
+	case pickManagerMsg:
+		// Closing leaves the shared bus connection open, so a load or
+		// action still running on the old manager finishes
+		m.bm.Close()
+		m.bm = msg.bm
+		return m, m.load()
+
+	case pickActionMsg:
+		if msg.err != nil {
+			m.setError(msg.err)
+		} else {
+			m.setStatus(msg.status)
+		}
+		return m, m.load()
+
+	case pickActivateMsg:
+		if msg.err != nil {
+			m.setError(msg.err)
+			return m, nil
+		}
+		return m, tea.Quit
+
+	case tea.KeyMsg:
+		if cmd, handled := m.handleKey(msg); handled {
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	query := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != query {
+		m.cursor = 0
+		m.applyQuery()
+	}
+	return m, cmd
+}
+
+// handleKey runs the key bindings; other keys go to the search input
+func (m *pickModel) handleKey(msg tea.KeyMsg) (tea.Cmd, bool) {
+	switch msg.String() {
+	case "ctrl+c", "esc":
+		return tea.Quit, true
+
+	case "up", "ctrl+p":
+		m.moveCursor(-1)
+	case "down", "ctrl+n":
+		m.moveCursor(1)
+	case "pgup":
+		m.moveCursor(-m.listHeight())
+	case "pgdown":
+		m.moveCursor(m.listHeight())
+
+	case "tab":
+		m.toggleMark()
+		m.moveCursor(1)
+	case "shift+tab":
+		m.toggleMark()
+		m.moveCursor(-1)
+	case "ctrl+a":
+		for _, i := range m.visible {
+			m.marked[m.tabs[i].ID] = true
+		}
+
+	case "enter":
+		tab, ok := m.current()
+		if !ok {
+			return nil, true
+		}
+		if pickPrint {
+			m.picked = m.targetIDs()
+			return tea.Quit, true
+		}
+		bm := m.bm
+		return func() tea.Msg {
+			result, err := bm.ActivateTab(tab.ID)
+			if err == nil {
+				err = client.ResultsError(result)
+			}
+			if err != nil {
+				return pickActivateMsg{err: fmt.Errorf("failed to activate tab %s: %w", tab.ID, err)}
+			}
+			return pickActivateMsg{}
+		}, true
+
+	case "ctrl+x":
+		return m.closeTabs(), true
+	case "ctrl+t":
+		return m.togglePinned(), true
+	case "ctrl+o":
+		return m.moveAfterCursor(), true
+	case "alt+o":
+		return m.moveToNewWindow(), true
+	case "ctrl+y":
+		return m.copyURLs(), true
+	case "ctrl+r":
+		m.setStatus("")
+		return m.load(), true
+
+	default:
+		return nil, false
+	}
+
+	m.scroll()
+	return nil, true
+}
+
+// applyQuery recomputes the visible tabs from --filter and the query,
+// keeping the cursor on the same tab when it is still visible
+func (m *pickModel) applyQuery() {
+	var current string
+	if tab, ok := m.current(); ok {
+		current = tab.ID
+	}
+
+	var candidates []int
+	for i, tab := range m.tabs {
+		if m.pred == nil || m.pred(tab) {
+			candidates = append(candidates, i)
+		}
+	}
+
+	query := strings.TrimSpace(m.input.Value())
+	if query == "" {
+		m.visible = candidates
+	} else {
+		targets := make([]string, len(candidates))
+		for i, index := range candidates {
+			targets[i] = m.tabs[index].Title + " " + m.tabs[index].URL
+		}
+		// Keep tab order so browser and window groups stay together
+		var visible []int
+		for _, rank := range list.DefaultFilter(query, targets) {
+			visible = append(visible, candidates[rank.Index])
+		}
+		sort.Ints(visible)
+		m.visible = visible
+	}
+
+	// Forget marks on tabs that are gone
+	open := make(map[string]bool, len(m.tabs))
+	for _, tab := range m.tabs {
+		open[tab.ID] = true
+	}
+	for tabID := range m.marked {
+		if !open[tabID] {
+			delete(m.marked, tabID)
+		}
+	}
+
+	for i, index := range m.visible {
+		if m.tabs[index].ID == current {
+			m.cursor = i
+		}
+	}
+	m.moveCursor(0)
+	m.scroll()
+}
+
+func (m *pickModel) moveCursor(delta int) {
+	m.cursor += delta
+	if m.cursor >= len(m.visible) {
+		m.cursor = len(m.visible) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *pickModel) toggleMark() {
+	if tab, ok := m.current(); ok {
+		if m.marked[tab.ID] {
+			delete(m.marked, tab.ID)
+		} else {
+			m.marked[tab.ID] = true
+		}
+	}
+}
+
+// current returns the tab under the cursor
+func (m *pickModel) current() (types.Tab, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.visible) {
+		return types.Tab{}, false
+	}
+	return m.tabs[m.visible[m.cursor]], true
+}
+
+// targets returns the marked tabs in list order, or the tab under the
+// cursor if none is marked
+func (m *pickModel) targets() []types.Tab {
+	var tabs []types.Tab
+	for _, tab := range m.tabs {
+		if m.marked[tab.ID] {
+			tabs = append(tabs, tab)
+		}
+	}
+	if len(tabs) == 0 {
+		if tab, ok := m.current(); ok {
+			tabs = append(tabs, tab)
+		}
+	}
+	return tabs
+}
+
+func (m *pickModel) targetIDs() []string {
+	var tabIDs []string
+	for _, tab := range m.targets() {
+		tabIDs = append(tabIDs, tab.ID)
+	}
+	return tabIDs
+}
+
+// action runs fn against the browsers in the background and reports
+// status when it succeeds
+func (m *pickModel) action(status string, fn func(bm *client.BrowserManager) error) tea.Cmd {
+	bm := m.bm
+	return func() tea.Msg {
+		return pickActionMsg{status: status, err: fn(bm)}
+	}
+}
+
+func (m *pickModel) closeTabs() tea.Cmd {
+	tabIDs := m.targetIDs()
+	if len(tabIDs) == 0 {
+		return nil
+	}
+	for _, tabID := range tabIDs {
+		delete(m.marked, tabID)
+	}
+	return m.action(fmt.Sprintf("Closed %d tab(s)", len(tabIDs)), func(bm *client.BrowserManager) error {
+		results, err := bm.CloseTabs(tabIDs)
+		if err == nil {
+			err = client.ResultsError(results...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to close tabs: %w", err)
+		}
+		return nil
+	})
+}
+
+// togglePinned unpins the targets if all are pinned, else pins them all
+func (m *pickModel) togglePinned() tea.Cmd {
+	tabs := m.targets()
+	if len(tabs) == 0 {
+		return nil
+	}
+
+	pin := false
+	for _, tab := range tabs {
+		if !tab.Pinned {
+			pin = true
+		}
+	}
+
+	updates := make([]types.TabUpdate, len(tabs))
+	for i, tab := range tabs {
+		updates[i] = types.TabUpdate{TabID: tab.ID, Properties: map[string]interface{}{"pinned": pin}}
+	}
+
+	status := fmt.Sprintf("Unpinned %d tab(s)", len(tabs))
+	if pin {
+		status = fmt.Sprintf("Pinned %d tab(s)", len(tabs))
+	}
+	return m.action(status, func(bm *client.BrowserManager) error {
+		results, err := bm.UpdateTabs(updates)
+		if err == nil {
+			err = client.ResultsError(results...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update tabs: %w", err)
+		}
+		return nil
+	})
+}
+
+// moveAfterCursor moves the marked tabs right after the tab under the
+// cursor, in list order
+func (m *pickModel) moveAfterCursor() tea.Cmd {
+	target, ok := m.current()
+	if !ok {
+		return nil
+	}
+
+	moves, err := movesAfter(target, m.targets())
+	if err != nil {
+		m.setError(err)
+		return nil
+	}
+	if len(moves) == 0 {
+		m.setStatus("Mark the tabs to move with tab, then put the cursor where they should go")
+		return nil
+	}
+
+	return m.moveTabs(moves)
+}
+
+// movesAfter returns the moves putting tabs right after target, in order.
+// The extension moves one tab at a time, and each tab moved from before the
+// target in its window shifts the target left by one.
+func movesAfter(target types.Tab, tabs []types.Tab) ([]types.TabMove, error) {
+	var moves []types.TabMove
+	shifted := 0
+	for _, tab := range tabs {
+		if tab.ID == target.ID {
+			continue
+		}
+		if utils.GetTabPrefix(tab.ID) != utils.GetTabPrefix(target.ID) {
+			return nil, fmt.Errorf("cannot move tab %s to a window of another browser", tab.ID)
+		}
+		index := target.Index - shifted + 1 + len(moves)
+		if tab.WindowID == target.WindowID && tab.Index < target.Index {
+			// Taking the tab out shifts the target and the tabs already
+			// moved after it
+			index--
+			shifted++
+		}
+		moves = append(moves, types.TabMove{TabID: tab.ID, WindowID: target.WindowID, Index: index})
+	}
+	return moves, nil
+}
+
+// moveToNewWindow gathers the marked tabs of each browser into a new window
+func (m *pickModel) moveToNewWindow() tea.Cmd {
+	var moves []types.TabMove
+	for _, tab := range m.targets() {
+		moves = append(moves, types.TabMove{TabID: tab.ID, WindowID: 0, Index: -1})
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+	return m.moveTabs(moves)
+}
+
+func (m *pickModel) moveTabs(moves []types.TabMove) tea.Cmd {
+	for _, move := range moves {
+		delete(m.marked, move.TabID)
+	}
+	return m.action(fmt.Sprintf("Moved %d tab(s)", len(moves)), func(bm *client.BrowserManager) error {
+		results, err := bm.MoveTabs(moves)
+		if err == nil {
+			err = client.ResultsError(results...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to move tabs: %w", err)
+		}
+		return nil
+	})
+}
+
+func (m *pickModel) copyURLs() tea.Cmd {
+	var urls []string
+	for _, tab := range m.targets() {
+		urls = append(urls, tab.URL)
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	if err := clipboard.WriteAll(strings.Join(urls, "\n")); err != nil {
+		m.setError(fmt.Errorf("failed to copy URLs: %w", err))
+	} else {
+		m.setStatus(fmt.Sprintf("Copied %d URL(s)", len(urls)))
+	}
+	return nil
+}
+
+func (m *pickModel) setStatus(status string) {
+	m.status, m.statusErr = status, false
+}
+
+func (m *pickModel) setError(err error) {
+	m.status, m.statusErr = err.Error(), true
+}
+
+// pickLine is a rendered line: a browser or window header, or a tab
+type pickLine struct {
+	header string
+	style  lipgloss.Style
+	tab    int // index into visible, -1 for headers
+}
+
+// lines groups the visible tabs under browser and window headers
+func (m *pickModel) lines() []pickLine {
+	var lines []pickLine
+	lastBrowser, lastWindow := "", ""
+	for i, index := range m.visible {
+		tab := m.tabs[index]
+		prefix := utils.GetTabPrefix(tab.ID)
+		browser := m.browsers[prefix]
+		if browser == "" {
+			browser = strings.TrimSuffix(prefix, ".")
+		}
+		window := prefix + strconv.Itoa(tab.WindowID)
+
+		if browser != lastBrowser {
+			lines = append(lines, pickLine{header: browser, style: pickBrowserStyle, tab: -1})
+			lastBrowser, lastWindow = browser, ""
+		}
+		if window != lastWindow {
+			lines = append(lines, pickLine{header: "  window " + window, style: pickWindowStyle, tab: -1})
+			lastWindow = window
+		}
+		lines = append(lines, pickLine{tab: i})
+	}
+	return lines
+}
+
+// listHeight is the number of lines available for the list, below the
+// search input and above the status and help lines
+func (m *pickModel) listHeight() int {
+	if m.height <= 3 {
+		return 1
+	}
+	return m.height - 3
+}
+
+// scroll keeps the cursor line, and the headers just above it, in view
+func (m *pickModel) scroll() {
+	lines := m.lines()
+	cursorLine := 0
+	for i, line := range lines {
+		if line.tab == m.cursor {
+			cursorLine = i
+			break
+		}
+	}
+
+	top := cursorLine
+	for top > 0 && lines[top-1].tab < 0 {
+		top--
+	}
+	if top < m.offset {
+		m.offset = top
+	}
+	if height := m.listHeight(); cursorLine >= m.offset+height {
+		m.offset = cursorLine - height + 1
+	}
+	if m.offset > len(lines)-1 {
+		m.offset = 0
+	}
+}
+
+// View renders the picker
+func (m pickModel) View() string {
+	var b strings.Builder
+	b.WriteString(m.input.View())
+	b.WriteString("\n")
+
+	lines := m.lines()
+	height := m.listHeight()
+	line := lipgloss.NewStyle().MaxWidth(m.width)
+	for i := m.offset; i < len(lines) && i < m.offset+height; i++ {
+		b.WriteString(line.Render(m.renderLine(lines[i])))
+		b.WriteString("\n")
+	}
+	for i := len(lines) - m.offset; i < height; i++ {
+		b.WriteString("\n")
+	}
+
+	switch {
+	case !m.loaded:
+		b.WriteString(pickDimStyle.Render("Loading tabs..."))
+	case m.status != "":
+		style := pickDimStyle
+		if m.statusErr {
+			style = pickErrorStyle
+		}
+		b.WriteString(line.Render(style.Render(m.status)))
+	default:
+		b.WriteString(pickDimStyle.Render(fmt.Sprintf("%d/%d tabs, %d marked", len(m.visible), len(m.tabs), len(m.marked))))
+	}
+	b.WriteString("\n")
+	b.WriteString(line.Render(pickDimStyle.Render("enter activate • tab mark • ^x close • ^t pin • ^o move • ^y copy • esc quit")))
+	return b.String()
+}
+
+// renderLine renders a header, or a tab as cursor, mark, flags, ID, title
+// and host
+func (m pickModel) renderLine(line pickLine) string {
+	if line.tab < 0 {
+		return line.style.Render(line.header)
+	}
+
+	tab := m.tabs[m.visible[line.tab]]
+	cursor := "  "
+	if line.tab == m.cursor {
+		cursor = pickCursorStyle.Render("> ")
+	}
+	mark := "  "
+	if m.marked[tab.ID] {
+		mark = pickMarkStyle.Render("* ")
+	}
+	flags := ""
+	if tab.Pinned {
+		flags += "📌 "
+	}
+	if tab.Active {
+		flags += "● "
+	}
+
+	title := tab.Title
+	if line.tab == m.cursor {
+		title = pickCursorStyle.Render(title)
+	}
+	return "  " + cursor + mark + pickDimStyle.Render(tab.ID) + "  " + flags + title + "  " + pickDimStyle.Render(pickHost(tab.URL))
+}
+
+// pickHost returns the host of a URL, or the URL itself if it has none
+func pickHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return u.Host
+}
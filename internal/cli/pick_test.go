@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// applyMoves moves tabs one at a time like the extension does, in windows
+// given as lists of tab IDs, and returns the windows
+func applyMoves(windows map[int][]string, moves []types.TabMove) map[int][]string {
+	for _, move := range moves {
+		for id, tabs := range windows {
+			if i := slices.Index(tabs, move.TabID); i >= 0 {
+				windows[id] = slices.Delete(tabs, i, i+1)
+			}
+		}
+		windows[move.WindowID] = slices.Insert(windows[move.WindowID], move.Index, move.TabID)
+	}
+	return windows
+}
+
+// pickTab returns the tab at index of a window in windows
+func pickTab(windows map[int][]string, windowID, index int) types.Tab {
+	return types.Tab{ID: windows[windowID][index], WindowID: windowID, Index: index}
+}
+
+func window(id, n int) []string {
+	tabs := make([]string, n)
+	for i := range tabs {
+		tabs[i] = fmt.Sprintf("f.%d.%d", id, id*10+i)
+	}
+	return tabs
+}
+
+func TestMovesAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		target [2]int         // window and index of the tab under the cursor
+		marked [][2]int       // window and index of each marked tab, in list order
+		want   map[int]string // tabs of the windows changed, by ID suffix
+	}{
+		{
+			name:   "two tabs before the target",
+			target: [2]int{1, 5},
+			marked: [][2]int{{1, 1}, {1, 2}},
+			want:   map[int]string{1: "10 13 14 15 11 12 16 17"},
+		},
+		{
+			name:   "three tabs before the target",
+			target: [2]int{1, 4},
+			marked: [][2]int{{1, 0}, {1, 1}, {1, 2}},
+			want:   map[int]string{1: "13 14 10 11 12 15 16 17"},
+		},
+		{
+			name:   "tabs after the target",
+			target: [2]int{1, 1},
+			marked: [][2]int{{1, 4}, {1, 6}},
+			want:   map[int]string{1: "10 11 14 16 12 13 15 17"},
+		},
+		{
+			name:   "tabs on both sides",
+			target: [2]int{1, 3},
+			marked: [][2]int{{1, 6}, {1, 0}, {1, 5}, {1, 2}},
+			want:   map[int]string{1: "11 13 16 10 15 12 14 17"},
+		},
+		{
+			name:   "tabs from another window",
+			target: [2]int{1, 2},
+			marked: [][2]int{{2, 0}, {1, 0}, {2, 2}},
+			want:   map[int]string{1: "11 12 20 10 22 13 14 15 16 17", 2: "21"},
+		},
+		{
+			name:   "target marked too",
+			target: [2]int{1, 2},
+			marked: [][2]int{{1, 0}, {1, 2}, {1, 7}},
+			want:   map[int]string{1: "11 12 10 17 13 14 15 16"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows := map[int][]string{1: window(1, 8), 2: window(2, 3)}
+			target := pickTab(windows, tt.target[0], tt.target[1])
+			var tabs []types.Tab
+			for _, mark := range tt.marked {
+				tabs = append(tabs, pickTab(windows, mark[0], mark[1]))
+			}
+
+			moves, err := movesAfter(target, tabs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			windows = applyMoves(windows, moves)
+			for id, want := range tt.want {
+				var got []string
+				for _, tabID := range windows[id] {
+					got = append(got, tabID[strings.LastIndex(tabID, ".")+1:])
+				}
+				if strings.Join(got, " ") != want {
+					t.Errorf("moves %+v leave window %d with %v, want %s", moves, id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMovesAfterOtherBrowser(t *testing.T) {
+	target := types.Tab{ID: "f.1.10", WindowID: 1}
+	if _, err := movesAfter(target, []types.Tab{{ID: "c.1.30", WindowID: 1}}); err == nil {
+		t.Error("movesAfter across browsers succeeded, want an error")
+	}
+}
@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/config"
+	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/internal/platform"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	rofiDmenu   bool
+	rofiSelect  bool
+	rofiIcons   bool
+	rofiRow     string
+	rofiIconDir string
+	rofiFilter  string
+)
+
+// faviconsDirName is the directory in the cache directory searched for
+// favicons, one "<domain>.png" per site
+const faviconsDirName = "favicons"
+
+// defaultRofiRow is the row template used without --row or the rofi_row
+// setting
+const defaultRofiRow = `{{.Title}} — {{.Domain}}`
+
+// dmenuIDSeparator separates the tab ID at the end of a dmenu row from the
+// rendered row
+const dmenuIDSeparator = "\t"
+
+// rofiCustomKey1 is the ROFI_RETV of kb-custom-1; kb-custom-2..19 follow
+const rofiCustomKey1 = 10
+
+var rofiCmd = &cobra.Command{
+	Use:   "rofi [selection]",
+	Short: "List tabs for rofi, dmenu or fuzzel and activate the chosen one",
+	Long: `Act as a rofi script mode, listing the tabs of all browsers and activating
+the tab chosen:
+
+  rofi -show tabs -modi "tabs:tabctl rofi" -show-icons
+
+Rows carry the tab ID as info and an icon: the site's favicon from --icon-dir
+if there is one ("<domain>.png", as saved by the rofi scripts), else the
+browser's icon. Pressing kb-custom-1 (Alt+1 by default) closes the tab under
+the cursor and keeps the menu open.
+
+With --dmenu, prints plain rows for any dmenu-like menu instead, and with
+--dmenu --select reads the chosen row on stdin and activates its tab:
+
+  tabctl rofi --dmenu | fuzzel --dmenu | tabctl rofi --dmenu --select
+
+Each dmenu row ends with a tab and the tab ID, which tells apart tabs whose
+rows are alike; rofi hides it with -display-columns 1.
+
+Rows are rendered with a Go template, set with --row or the rofi_row setting.
+The default is "` + defaultRofiRow + `".
+
+` + templateHelp,
+	Example: `  tabctl rofi --row '{{.Browser}} {{.Window}}  {{.Title}}  {{.Domain}}'
+  tabctl rofi --dmenu --icons | rofi -dmenu -show-icons -display-columns 1 | tabctl rofi --dmenu --select`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRofi(args)
+	},
+}
+
+func init() {
+	rofiCmd.Flags().BoolVar(&rofiDmenu, "dmenu", false, "print plain rows for dmenu-like menus")
+	rofiCmd.Flags().BoolVar(&rofiSelect, "select", false, "with --dmenu, read the chosen row on stdin and activate its tab")
+	rofiCmd.Flags().BoolVar(&rofiIcons, "icons", false, "with --dmenu, add rofi/fuzzel icon metadata to the rows")
+	rofiCmd.Flags().StringVar(&rofiRow, "row", "", "row template (default: rofi_row setting or \""+defaultRofiRow+"\")")
+	rofiCmd.Flags().StringVar(&rofiIconDir, "icon-dir", "", "directory of <domain>.png favicons (default: favicons in the tabctl cache directory)")
+	rofiCmd.Flags().StringVar(&rofiFilter, "filter", "", filterHelp)
+}
+
+// rofiMenu renders tabs as menu rows
+type rofiMenu struct {
+	row      *template.Template
+	iconDir  string
+	browsers map[string]string // prefix -> browser name
+}
+
+func runRofi(args []string) error {
+	if rofiSelect && !rofiDmenu {
+		return fmt.Errorf("--select requires --dmenu")
+	}
+
+	menu, err := newRofiMenu()
+	if err != nil {
+		return err
+	}
+
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+	menu.browsers = tabBrowsers(bm)
+
+	if rofiDmenu {
+		if rofiSelect {
+			return runDmenuSelect(bm, menu)
+		}
+		return printRofiRows(bm, menu, rofiIcons, true)
+	}
+
+	// Script mode: rofi runs us again with the chosen row and ROFI_RETV set
+	retv, _ := strconv.Atoi(os.Getenv("ROFI_RETV"))
+	tabID := os.Getenv("ROFI_INFO")
+	switch {
+	case len(args) == 0 || retv == 0:
+		return printRofiMode(bm, menu)
+	case tabID == "":
+		// A custom entry; there is nothing to act on
+		return nil
+	case retv == rofiCustomKey1:
+		results, err := bm.CloseTabs([]string{tabID})
+		if err == nil {
+			err = client.ResultsError(results...)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to close tab %s: %w", tabID, err)
+		}
+		return printRofiMode(bm, menu)
+	default:
+		result, err := bm.ActivateTab(tabID)
+		if err == nil {
+			err = client.ResultsError(result)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to activate tab %s: %w", tabID, err)
+		}
+		return nil
+	}
+}
+
+// newRofiMenu parses the row template and resolves the favicon directory
+func newRofiMenu() (*rofiMenu, error) {
+	row := rofiRow
+	if row == "" {
+		row = currentSettings().String(config.KeyRofiRow)
+	}
+	if row == "" {
+		row = defaultRofiRow
+	}
+	tmpl, err := parseTemplate("row", row)
+	if err != nil {
+		return nil, err
+	}
+
+	iconDir := rofiIconDir
+	if iconDir == "" {
+		cacheDir, err := platform.GetCacheDir()
+		if err != nil {
+			return nil, err
+		}
+		iconDir = filepath.Join(cacheDir, faviconsDirName)
+	}
+
+	return &rofiMenu{row: tmpl, iconDir: iconDir}, nil
+}
+
+// printRofiMode prints the script mode options followed by the rows
+func printRofiMode(bm *client.BrowserManager, menu *rofiMenu) error {
+	fmt.Print("\x00prompt\x1ftabs\n")
+	fmt.Print("\x00no-custom\x1ftrue\n")
+	fmt.Print("\x00use-hot-keys\x1ftrue\n")
+	return printRofiRows(bm, menu, true, false)
+}
+
+// printRofiRows prints one row per tab, ending with the tab ID if withID is
+// set and with icon and info metadata if meta is set
+func printRofiRows(bm *client.BrowserManager, menu *rofiMenu, meta, withID bool) error {
+	tabs, err := rofiTabs(bm)
+	if err != nil {
+		return err
+	}
+
+	for _, tab := range tabs {
+		text, err := menu.render(tab)
+		if err != nil {
+			return err
+		}
+		if withID {
+			text += dmenuIDSeparator + tab.ID
+		}
+		if meta {
+			text += "\x00icon\x1f" + menu.icon(tab) + "\x1finfo\x1f" + tab.ID
+		}
+		fmt.Println(text)
+	}
+	return nil
+}
+
+// runDmenuSelect activates the tab of the row read on stdin, named by the
+// tab ID at its end. Rows the menu returned without the ID are matched by
+// rendering the tabs again, so the template must be the same as when
+// listing, and the first of identical rows wins.
+func runDmenuSelect(bm *client.BrowserManager, menu *rofiMenu) error {
+	lines, err := utils.ReadStdinLines()
+	if err != nil {
+		return fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil // menu cancelled
+	}
+	// Icon metadata may come back with the row, and menus may keep or drop
+	// the trailing blanks of a row, so both sides are compared trimmed
+	choice, tabID := parseDmenuRow(lines[0])
+	if tabID != "" {
+		return activateDmenuTab(bm, tabID)
+	}
+
+	tabs, err := rofiTabs(bm)
+	if err != nil {
+		return err
+	}
+	for _, tab := range tabs {
+		text, err := menu.render(tab)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(text) == choice {
+			return activateDmenuTab(bm, tab.ID)
+		}
+	}
+
+	return fmt.Errorf("no tab matches %q", choice)
+}
+
+// parseDmenuRow returns the rendered text of a row read back from a menu,
+// trimmed, and the tab ID at its end, or "" if the menu dropped it
+func parseDmenuRow(line string) (text, tabID string) {
+	text, _, _ = strings.Cut(line, "\x00")
+	if i := strings.LastIndex(text, dmenuIDSeparator); i >= 0 {
+		if id := strings.TrimSpace(text[i+len(dmenuIDSeparator):]); utils.ValidateTabID(id) == nil {
+			return strings.TrimSpace(text[:i]), id
+		}
+	}
+	return strings.TrimSpace(text), ""
+}
+
+func activateDmenuTab(bm *client.BrowserManager, tabID string) error {
+	result, err := bm.ActivateTab(tabID)
+	if err == nil {
+		err = client.ResultsError(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to activate tab %s: %w", tabID, err)
+	}
+	return nil
+}
+
+// rofiTabs lists the tabs to show, applying --filter
+func rofiTabs(bm *client.BrowserManager) ([]types.Tab, error) {
+	if rofiFilter != "" {
+		return filteredTabs(bm, rofiFilter)
+	}
+	tabs, err := bm.ListAllTabs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+	return tabs, nil
+}
+
+// render executes the row template for a tab. Newlines would split the row
+// and NUL starts metadata, so both are replaced.
+func (m *rofiMenu) render(tab types.Tab) (string, error) {
+	var b strings.Builder
+	if err := m.row.Execute(&b, newTabRow(tab, m.browsers)); err != nil {
+		return "", fmt.Errorf("invalid row template: %w", err)
+	}
+	return strings.NewReplacer("\n", " ", "\r", " ", "\x00", "").Replace(b.String()), nil
+}
+
+// icon returns the favicon of the tab's site if it is in the icon
+// directory, else the icon name of its browser
+func (m *rofiMenu) icon(tab types.Tab) string {
+	if domain := domain(tab.URL); domain != "" {
+		path := filepath.Join(m.iconDir, domain+".png")
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return browserIcon(m.browsers[utils.GetTabPrefix(tab.ID)])
+}
+
+// browserIcons maps browsers to their freedesktop icon names
+var browserIcons = map[string]string{
+	"chrome": "google-chrome",
+	"brave":  "brave-browser",
+	"edge":   "microsoft-edge",
+	"zen":    "zen-browser",
+}
+
+// browserIcon returns the icon name of a mediator's browser
+func browserIcon(name string) string {
+	browser := strings.ToLower(dbus.ParseBrowserName(name).Browser)
+	if icon, ok := browserIcons[browser]; ok {
+		return icon
+	}
+	if browser == "" {
+		return "web-browser"
+	}
+	return browser
+}
@@ -0,0 +1,24 @@
+package cli
+
+import "testing"
+
+func TestParseDmenuRow(t *testing.T) {
+	tests := []struct {
+		name, line, text, tabID string
+	}{
+		{"row with ID", "Docs — example.com\tf.1.10", "Docs — example.com", "f.1.10"},
+		{"icon metadata", "Docs\tc.1874583011.1874583012\x00icon\x1ffirefox\x1finfo\x1fc.1874583011.1874583012", "Docs", "c.1874583011.1874583012"},
+		{"trailing blanks", "Docs  \tf.1.10 ", "Docs", "f.1.10"},
+		{"tab in the row itself", "a\tb\tf.2.20", "a\tb", "f.2.20"},
+		{"ID dropped by the menu", "Docs — example.com", "Docs — example.com", ""},
+		{"no ID after the tab", "Docs\tnotes", "Docs\tnotes", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, tabID := parseDmenuRow(tt.line)
+			if text != tt.text || tabID != tt.tabID {
+				t.Errorf("parseDmenuRow(%q) = %q, %q, want %q, %q", tt.line, text, tabID, tt.text, tt.tabID)
+			}
+		})
+	}
+}
@@ -1,16 +1,28 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/config"
 )
 
 var (
-	outputFormat  string = "tsv"  // Output format: tsv, json, simple
-	delimiter     string = "\t"   // Field delimiter
-	noHeaders     bool            // Suppress headers in output
-	targetBrowser string = ""     // Target specific browser (empty = all)
+	outputFormat   string        = "tsv" // Output format: tsv, csv, json, ndjson, yaml, simple, template
+	outputTemplate string                // Template of --format template
+	outputColumns  []string              // Columns of TSV tab lists (empty = id, title, url)
+	delimiter      string        = "\t"  // Field delimiter
+	noHeaders      bool                  // Suppress headers in output
+	targetBrowser  string        = ""    // Target specific browser (empty = all)
+	callTimeout    time.Duration         // Deadline for each D-Bus call (0 = none)
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -19,10 +31,18 @@ var rootCmd = &cobra.Command{
 	Short: "Control your browser's tabs from the command line",
 	Long: `tabctl (tab control) is a command-line tool that helps you manage
 browser tabs via D-Bus. It can list, close, and activate tabs across
-Firefox and Chrome-based browsers.`,
+Firefox and Chrome-based browsers.
+
+Exit status: 0 on success, 1 on errors, 2 if a command acting on tabs
+(close, activate, move) failed for some of them, 3 if it failed for all of
+them, 4 if no browser is connected and 130 if interrupted.`,
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: true,
 	},
+	// Settings from the config file and environment fill in the flags not given
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applySettings(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
 			fmt.Println("No command has been specified")
@@ -31,21 +51,102 @@ Firefox and Chrome-based browsers.`,
 	},
 }
 
+// Exit codes of tabctl
+const (
+	ExitError          = 1   // the command failed
+	ExitPartialFailure = 2   // the command failed for some of the tabs
+	ExitFailure        = 3   // the command failed for every tab
+	ExitNoBrowsers     = 4   // no browser is connected
+	ExitInterrupted    = 130 // interrupted with Ctrl-C or SIGTERM
+)
+
+// exitError is an error ending tabctl with its own exit code
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() error {
-	return rootCmd.Execute()
+	// Ctrl-C cancels the browser calls in flight; a second one kills tabctl.
+	// The context is only ever cancelled by a signal, which ExitCode relies on.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// ExitCode returns the exit code for an error returned by Execute. An
+// interrupted command exits with ExitInterrupted even if it printed partial
+// results.
+func ExitCode(err error) int {
+	var exitErr *exitError
+	switch {
+	case rootCmd.Context() != nil && rootCmd.Context().Err() != nil:
+		return ExitInterrupted
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	case errors.Is(err, client.ErrNoBrowsers):
+		return ExitNoBrowsers
+	}
+	return ExitError
+}
+
+// newBrowserManager connects to the browsers selected with --browser. Its
+// calls are cancelled on Ctrl-C.
+func newBrowserManager() *client.BrowserManager {
+	ctx := rootCmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return client.NewBrowserManagerContext(ctx, targetBrowser, callTimeout)
+}
+
+// closeBrowserManager warns on stderr about the browsers that failed to
+// answer the last call to all of them, then closes bm
+func closeBrowserManager(bm *client.BrowserManager) {
+	for _, failure := range bm.Failures() {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", failure)
+	}
+	bm.Close()
 }
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "tsv", "Output format: tsv, json, simple")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "format", "tsv", "Output format: tsv, csv, json, ndjson, yaml, simple, template (tab lists)")
+	rootCmd.PersistentFlags().StringVar(&outputTemplate, "template", "", "Go template of each tab for --format template, e.g. '{{.ID}} {{.Title | trunc 60}}'")
+	rootCmd.PersistentFlags().StringSliceVar(&outputColumns, "columns", nil, "Columns of TSV tab lists: "+strings.Join(tabColumnNames(), ", ")+" (default id,title,url)")
 	rootCmd.PersistentFlags().StringVar(&delimiter, "delimiter", "\t", "Field delimiter for TSV output")
 	rootCmd.PersistentFlags().BoolVar(&noHeaders, "no-headers", false, "Suppress headers in output")
-	rootCmd.PersistentFlags().StringVar(&targetBrowser, "browser", "", "Target specific browser (e.g., Firefox, Brave)")
+	rootCmd.PersistentFlags().StringVar(&targetBrowser, "browser", "", "Target specific browser, profile or instance (e.g., Firefox, Firefox:work, Firefox:work#2)")
+	rootCmd.PersistentFlags().DurationVar(&callTimeout, "timeout", config.DBusCallTimeout, "Maximum time to wait for each browser call (0 = no limit)")
 
 	// Add subcommands
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(closeCmd)
 	rootCmd.AddCommand(activateCmd)
+	rootCmd.AddCommand(moveCmd)
+	rootCmd.AddCommand(pickCmd)
+	rootCmd.AddCommand(rofiCmd)
+	rootCmd.AddCommand(dedupeCmd)
+	rootCmd.AddCommand(sessionCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(openCmd)
+	rootCmd.AddCommand(textCmd)
+	rootCmd.AddCommand(htmlCmd)
+	rootCmd.AddCommand(wordsCmd)
+	rootCmd.AddCommand(screenshotCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(daemonCmd)
+	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(installCmd)
-}
\ No newline at end of file
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/platform"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	screenshotOutput     string
+	screenshotAllWindows bool
+)
+
+var screenshotCmd = &cobra.Command{
+	Use:   "screenshot [tab_id]",
+	Short: "Save a PNG screenshot of a tab",
+	Long: `Capture the visible area of a tab as PNG. The tab is activated first if
+it is not the visible tab of its window. Without a tab ID, the active tab of
+the last focused window is captured. The extension needs access to all
+websites; in Firefox, allow it in about:addons.
+
+The PNG is written to --output, or to stdout when it is not a terminal.
+
+With --all-windows, the active tab of every window is captured and saved as
+"<prefix>.<window_id>.png" in the --output directory (default: current
+directory); the written paths are printed one per line.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if screenshotAllWindows {
+			if len(args) > 0 {
+				return fmt.Errorf("--all-windows does not take a tab ID")
+			}
+			return runScreenshotAllWindows()
+		}
+
+		tabID := ""
+		if len(args) > 0 {
+			tabID = args[0]
+		}
+		return runScreenshot(tabID)
+	},
+}
+
+func init() {
+	screenshotCmd.Flags().StringVarP(&screenshotOutput, "output", "o", "", `output file ("-" for stdout), or directory with --all-windows`)
+	screenshotCmd.Flags().BoolVar(&screenshotAllWindows, "all-windows", false, "capture the active tab of every window")
+}
+
+func runScreenshot(tabID string) error {
+	toStdout := screenshotOutput == "" || screenshotOutput == "-"
+	if toStdout && utils.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("refusing to write PNG data to a terminal; use --output or redirect stdout")
+	}
+
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	var screenshot *types.Screenshot
+	if tabID != "" {
+		var err error
+		screenshot, err = bm.CaptureTab(tabID)
+		if err != nil {
+			return fmt.Errorf("failed to capture tab: %w", err)
+		}
+	} else {
+		browser, err := bm.SelectClient("", defaultBrowser())
+		if err != nil {
+			return fmt.Errorf("failed to capture tab: %w", err)
+		}
+		screenshot, err = browser.GetScreenshot("")
+		if err != nil {
+			return fmt.Errorf("failed to capture tab: %w", err)
+		}
+	}
+
+	if toStdout {
+		return utils.WriteToStdout(screenshot.Data)
+	}
+
+	if err := os.WriteFile(screenshotOutput, screenshot.Data, 0644); err != nil {
+		return fmt.Errorf("failed to write screenshot: %w", err)
+	}
+	fmt.Printf("Saved screenshot of tab %s to %s\n", screenshot.TabID, screenshotOutput)
+	return nil
+}
+
+func runScreenshotAllWindows() error {
+	dir := screenshotOutput
+	if dir == "" {
+		dir = "."
+	}
+	if err := platform.EnsureDir(dir); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	screenshots, err := bm.CaptureWindows()
+	if err != nil {
+		return fmt.Errorf("failed to capture windows: %w", err)
+	}
+
+	for _, screenshot := range screenshots {
+		path := filepath.Join(dir, screenshot.WindowID+".png")
+		if err := os.WriteFile(path, screenshot.Data, 0644); err != nil {
+			return fmt.Errorf("failed to write screenshot: %w", err)
+		}
+		fmt.Println(path)
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/search"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	searchRefresh bool
+	searchLimit   int
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [query...]",
+	Short: "Full-text search over the text of open tabs",
+	Long: `Search the text, titles and URLs of open tabs. Results are ranked best
+first; output columns are tab ID, title, URL and a snippet of the matching
+text, so the first column can be piped into "tabctl activate".
+
+Every word of the query must match. Double-quoted phrases must appear
+verbatim.
+
+The index lives in the tabctl cache directory. It is built on first use and
+refreshed with --refresh, which reads the text of every loaded tab. Running
+"tabctl search --refresh" without a query only refreshes the index.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSearch(strings.Join(args, " "))
+	},
+}
+
+func init() {
+	searchCmd.Flags().BoolVar(&searchRefresh, "refresh", false, "re-read the text of all tabs before searching")
+	searchCmd.Flags().IntVar(&searchLimit, "limit", 20, "maximum number of results (0 = no limit)")
+}
+
+func runSearch(query string) error {
+	if query == "" && !searchRefresh {
+		return fmt.Errorf("no search query given")
+	}
+
+	path, err := search.DefaultPath()
+	if err != nil {
+		return fmt.Errorf("failed to locate search index: %w", err)
+	}
+	idx, err := search.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if searchRefresh || idx.Len() == 0 {
+		count, err := refreshSearchIndex(idx)
+		if err != nil {
+			return err
+		}
+		if query == "" {
+			fmt.Printf("Indexed %d tab(s)\n", count)
+			return nil
+		}
+	}
+
+	results, err := idx.Search(query)
+	if err != nil {
+		return err
+	}
+	if searchLimit > 0 && len(results) > searchLimit {
+		results = results[:searchLimit]
+	}
+
+	return formatSearchResults(results)
+}
+
+// refreshSearchIndex indexes the text of every loaded tab
+func refreshSearchIndex(idx *search.Index) (int, error) {
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	contents, err := bm.GetText(nil, types.TextOptions{Cleanup: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read tab text: %w", err)
+	}
+
+	warnUnreadBrowsers(contents, tabBrowsers(bm))
+
+	if err := idx.IndexTabs(contents); err != nil {
+		return 0, err
+	}
+	return len(contents), nil
+}
+
+// warnUnreadBrowsers warns about browsers whose tabs all came back without
+// text, which is what an extension without access to the pages returns.
+// Such tabs can only match by title and URL.
+func warnUnreadBrowsers(contents []types.TabContent, browsers map[string]string) {
+	tabs := make(map[string]int)
+	unread := make(map[string]int)
+	var prefixes []string
+	for _, content := range contents {
+		prefix := utils.GetTabPrefix(content.TabID)
+		if tabs[prefix] == 0 {
+			prefixes = append(prefixes, prefix)
+		}
+		tabs[prefix]++
+		if strings.TrimSpace(content.Content) == "" {
+			unread[prefix]++
+		}
+	}
+
+	for _, prefix := range prefixes {
+		if unread[prefix] < tabs[prefix] {
+			continue
+		}
+		browser := browsers[prefix]
+		if browser == "" {
+			browser = strings.TrimSuffix(prefix, ".")
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s: no text in any of its %d tab(s); is the extension allowed to access all websites?\n", browser, tabs[prefix])
+	}
+}
+
+// formatSearchResults outputs search results as tab ID, title, URL and
+// snippet, or plain tab IDs with simple
+func formatSearchResults(results []types.SearchResult) error {
+	enc, err := newEncoder("id", "title", "url", "snippet")
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		err := enc.Encode(record{
+			Value:  result,
+			Fields: []string{result.TabID, result.Title, result.URL, result.Snippet},
+			Line:   result.TabID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
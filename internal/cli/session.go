@@ -0,0 +1,322 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/session"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	sessionForce bool
+)
+
+var sessionCmd = &cobra.Command{
+	Use:   "session",
+	Short: "Save and restore sets of windows and tabs",
+	Long: `Save the windows and tabs of all connected browsers under a name and reopen
+them later. Sessions are stored as JSON files in the "` + session.SessionsDirName + `" directory
+of the tabctl config directory.`,
+}
+
+var sessionSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save the open windows and tabs",
+	Long: `Save the windows and tabs of all connected browsers (or the one given with
+--browser): tab order, title, URL and pinned state, grouped by browser and
+window.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSessionSave(args[0])
+	},
+}
+
+var sessionRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Reopen a saved session",
+	Long: `Reopen every window of a saved session as a new window, pinning the tabs
+that were pinned. Windows of a browser that is not connected open in the same
+browser with another profile if there is one, else in the default_browser
+setting or the first browser by name. Tabs of pages extensions cannot open,
+such as about:preferences or file: URLs, are skipped and counted.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSessionRestore(args[0])
+	},
+}
+
+var sessionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved sessions",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSessionList()
+	},
+}
+
+var sessionDiffCmd = &cobra.Command{
+	Use:   "diff <name>",
+	Short: "Compare a saved session with the open tabs",
+	Long: `Compare a saved session with the open tabs by URL, ignoring fragments and
+tracking parameters. Prints "missing" for saved tabs that are no longer open
+and "added" for open tabs that are not in the session.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSessionDiff(args[0])
+	},
+}
+
+var sessionDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a saved session",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSessionDelete(args[0])
+	},
+}
+
+func init() {
+	sessionSaveCmd.Flags().BoolVar(&sessionForce, "force", false, "overwrite an existing session")
+
+	sessionCmd.AddCommand(sessionSaveCmd)
+	sessionCmd.AddCommand(sessionRestoreCmd)
+	sessionCmd.AddCommand(sessionListCmd)
+	sessionCmd.AddCommand(sessionDiffCmd)
+	sessionCmd.AddCommand(sessionDeleteCmd)
+}
+
+func runSessionSave(name string) error {
+	if err := session.ValidateName(name); err != nil {
+		return err
+	}
+
+	store, err := session.DefaultStore()
+	if err != nil {
+		return err
+	}
+	if store.Exists(name) && !sessionForce {
+		return fmt.Errorf("session %q already exists (use --force to overwrite)", name)
+	}
+
+	sess, err := captureSession(name)
+	if err != nil {
+		return err
+	}
+	if err := store.Save(sess); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved session %s: %d window(s), %d tab(s)\n", name, session.WindowCount(sess), session.TabCount(sess))
+	return nil
+}
+
+// captureSession records the tabs of the browsers selected with --browser
+func captureSession(name string) (types.Session, error) {
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	if len(bm.GetClients()) == 0 {
+		return types.Session{}, client.ErrNoBrowsers
+	}
+	return session.Capture(name, bm.GetClients())
+}
+
+func runSessionRestore(name string) error {
+	store, err := session.DefaultStore()
+	if err != nil {
+		return err
+	}
+	sess, err := store.Load(name)
+	if err != nil {
+		return err
+	}
+
+	return restoreSession(sess)
+}
+
+// restoreSession reopens a session and reports where each browser went
+func restoreSession(sess types.Session) error {
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	results, err := session.Restore(bm, sess, defaultBrowser())
+	if err != nil {
+		return fmt.Errorf("failed to restore session: %w", err)
+	}
+
+	if err := formatRestoreResults(results); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if result.Error != "" {
+			return fmt.Errorf("failed to restore some windows of session %s", sess.Name)
+		}
+	}
+	return nil
+}
+
+// formatRestoreResults outputs one record per restored browser: saved
+// browser, target browser, windows, tabs, skipped tabs and error, followed
+// by a summary with tsv; simple prints the opened tab IDs
+func formatRestoreResults(results []session.RestoreResult) error {
+	enc, err := newEncoder("browser", "target", "windows", "tabs", "skipped", "error")
+	if err != nil {
+		return err
+	}
+
+	windows, tabs, skipped := 0, 0, 0
+	for _, result := range results {
+		windows += result.Windows
+		tabs += len(result.TabIDs)
+		skipped += len(result.Skipped)
+		if outputFormat == "simple" && len(result.TabIDs) == 0 {
+			continue
+		}
+		err := enc.Encode(record{
+			Value: result,
+			Fields: []string{
+				result.Browser,
+				result.Target,
+				strconv.Itoa(result.Windows),
+				strconv.Itoa(len(result.TabIDs)),
+				strconv.Itoa(len(result.Skipped)),
+				result.Error,
+			},
+			Line: strings.Join(result.TabIDs, "\n"),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if outputFormat == "tsv" {
+		fmt.Printf("Restored %d tab(s) in %d window(s)\n", tabs, windows)
+		if skipped > 0 {
+			fmt.Printf("Skipped %d tab(s) of pages extensions cannot open (about:, file:, ...)\n", skipped)
+		}
+	}
+	return nil
+}
+
+// sessionSummary is the JSON form of a saved session in session list
+type sessionSummary struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Browsers []string  `json:"browsers"`
+	Windows  int       `json:"windows"`
+	Tabs     int       `json:"tabs"`
+}
+
+func runSessionList() error {
+	store, err := session.DefaultStore()
+	if err != nil {
+		return err
+	}
+	sessions, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	return formatSessionList(sessions)
+}
+
+// formatSessionList outputs sessions as TSV (name, created, windows, tabs,
+// browsers), JSON summaries or plain names
+func formatSessionList(sessions []types.Session) error {
+	summaries := make([]sessionSummary, 0, len(sessions))
+	for _, sess := range sessions {
+		summary := sessionSummary{
+			Name:     sess.Name,
+			Created:  sess.Created,
+			Browsers: []string{},
+			Windows:  session.WindowCount(sess),
+			Tabs:     session.TabCount(sess),
+		}
+		for _, browser := range sess.Browsers {
+			summary.Browsers = append(summary.Browsers, browser.Browser)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	enc, err := newEncoder("name", "created", "windows", "tabs", "browsers")
+	if err != nil {
+		return err
+	}
+	enc.Empty = "No sessions found"
+	for _, summary := range summaries {
+		err := enc.Encode(record{
+			Value: summary,
+			Fields: []string{
+				summary.Name,
+				summary.Created.Local().Format(time.RFC3339),
+				strconv.Itoa(summary.Windows),
+				strconv.Itoa(summary.Tabs),
+				strings.Join(summary.Browsers, ","),
+			},
+			Line: summary.Name,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+func runSessionDiff(name string) error {
+	store, err := session.DefaultStore()
+	if err != nil {
+		return err
+	}
+	saved, err := store.Load(name)
+	if err != nil {
+		return err
+	}
+
+	current, err := captureSession("")
+	if err != nil {
+		return err
+	}
+
+	changes := session.Diff(saved, current)
+	enc, err := newEncoder("change", "browser", "title", "url")
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		sign := "-"
+		if change.Kind == session.ChangeAdded {
+			sign = "+"
+		}
+		err := enc.Encode(record{
+			Value:  change,
+			Fields: []string{change.Kind, change.Browser, change.Title, change.URL},
+			Line:   sign + " " + change.URL,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+func runSessionDelete(name string) error {
+	store, err := session.DefaultStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Delete(name); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted session %s\n", name)
+	return nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/config"
+)
+
+// settingFlags maps settings to the global flags they give defaults for
+var settingFlags = map[string]string{
+	"format":     "format",
+	"delimiter":  "delimiter",
+	"no_headers": "no-headers",
+	"browser":    "browser",
+	"timeout":    "timeout",
+	"columns":    "columns",
+	"template":   "template",
+}
+
+// applySettings loads the config file and the environment and uses them for
+// the global flags not given on the command line, then resolves a browser
+// alias in --browser
+func applySettings(cmd *cobra.Command) error {
+	settings, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	for key, flag := range settingFlags {
+		entry, _ := settings.Get(key)
+		if entry.Source == config.SourceDefault || cmd.Flags().Changed(flag) {
+			continue
+		}
+		if err := cmd.Flags().Set(flag, entry.Value); err != nil {
+			return fmt.Errorf("invalid %s setting from %s: %w", key, entry.Source, err)
+		}
+	}
+
+	targetBrowser = resolveAlias(targetBrowser)
+	return nil
+}
+
+// currentSettings returns the settings, with the defaults and environment
+// only if the config file is broken
+func currentSettings() *config.Settings {
+	settings, _ := config.Load()
+	return settings
+}
+
+// resolveAlias returns the browser an alias from the aliases section stands
+// for, or name itself
+func resolveAlias(name string) string {
+	if name == "" {
+		return name
+	}
+	for alias, browser := range currentSettings().Section(config.SectionAliases) {
+		if strings.EqualFold(alias, name) {
+			return browser
+		}
+	}
+	return name
+}
+
+// defaultBrowser returns the browser preferred by commands acting on a
+// single browser
+func defaultBrowser() string {
+	return resolveAlias(currentSettings().String(config.KeyDefaultBrowser))
+}
+
+// resolveFilter replaces a named filter ("@name") with its expression from
+// the filters section
+func resolveFilter(expr string) (string, error) {
+	name, ok := strings.CutPrefix(strings.TrimSpace(expr), "@")
+	if !ok {
+		return expr, nil
+	}
+	for filterName, filterExpr := range currentSettings().Section(config.SectionFilters) {
+		if strings.EqualFold(filterName, name) {
+			return filterExpr, nil
+		}
+	}
+	return "", fmt.Errorf("unknown filter @%s (define it with \"tabctl config set filters.%s <expression>\")", name, name)
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/config"
+)
+
+// The settings are loaded once per process, so this is the only test reading
+// the config file
+func TestApplySettingsPrecedence(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	if err := os.MkdirAll(filepath.Join(configHome, "tabctl"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	content := "format: json\ntimeout: 10s\ndelimiter: \",\"\nbrowser: work\naliases:\n  work: Firefox:work\n"
+	if err := os.WriteFile(filepath.Join(configHome, "tabctl", config.FileName), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TABCTL_FORMAT", "csv")
+	t.Setenv("TABCTL_TIMEOUT", "20s")
+	t.Setenv("TABCTL_COLUMNS", "id,url")
+
+	savedBrowser := targetBrowser
+	t.Cleanup(func() { targetBrowser = savedBrowser })
+
+	var (
+		format, delimiter, template string
+		noHeaders                   bool
+		timeout                     time.Duration
+		columns                     []string
+	)
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringVar(&format, "format", "tsv", "")
+	cmd.Flags().StringVar(&delimiter, "delimiter", "\t", "")
+	cmd.Flags().BoolVar(&noHeaders, "no-headers", false, "")
+	cmd.Flags().StringVar(&targetBrowser, "browser", "", "")
+	cmd.Flags().DurationVar(&timeout, "timeout", config.DBusCallTimeout, "")
+	cmd.Flags().StringSliceVar(&columns, "columns", defaultColumns, "")
+	cmd.Flags().StringVar(&template, "template", "", "")
+
+	if err := cmd.Flags().Parse([]string{"--timeout", "5s", "--columns", "title"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applySettings(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"format (env over file)", format, "csv"},
+		{"timeout (flag over env and file)", timeout, 5 * time.Second},
+		{"columns (flag over env)", len(columns) == 1 && columns[0] == "title", true},
+		{"delimiter (file over default)", delimiter, ","},
+		{"no-headers (default)", noHeaders, false},
+		{"browser (file, alias resolved)", targetBrowser, "Firefox:work"},
+	}
+	for _, check := range checks {
+		if check.got != check.want {
+			t.Errorf("%s = %v, want %v", check.name, check.got, check.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	watchEvents   []string
+	watchURLMatch string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream live tab events",
+	Long: `Stream tab events from every browser connected via D-Bus, one per line.
+Browsers that connect or disconnect while watching are picked up automatically.
+
+Event names: created, removed, updated, activated, moved, focused,
+connected, disconnected.
+
+Use --format json or ndjson for newline-delimited JSON, and --format yaml for
+a stream of YAML documents.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWatch(cmd.Context())
+	},
+}
+
+func init() {
+	watchCmd.Flags().StringSliceVar(&watchEvents, "event", nil, "only show these events (comma-separated)")
+	watchCmd.Flags().StringVar(&watchURLMatch, "url-match", "", "only show events for tabs whose URL matches this regular expression")
+}
+
+func runWatch(ctx context.Context) error {
+	var urlPattern *regexp.Regexp
+	if watchURLMatch != "" {
+		var err error
+		urlPattern, err = regexp.Compile(watchURLMatch)
+		if err != nil {
+			return fmt.Errorf("invalid --url-match pattern: %w", err)
+		}
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range watchEvents {
+		wanted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+
+	// Ctrl-C cancels the command's context and ends the stream; a closed
+	// stdout ends the loop via write errors
+	events, err := client.WatchEvents(ctx, callTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to watch events: %w", err)
+	}
+
+	enc, err := newStreamEncoder("time", "browser", "event", "id", "window", "title", "url")
+	if err != nil {
+		return err
+	}
+	for event := range events {
+		if targetBrowser != "" && !client.MatchesBrowser(event.Browser, targetBrowser) {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[event.Event] {
+			continue
+		}
+		if urlPattern != nil && !urlPattern.MatchString(event.URL) {
+			continue
+		}
+
+		if err := enc.Encode(eventRecord(event)); err != nil {
+			return err
+		}
+	}
+
+	return enc.Close()
+}
+
+// eventRecord renders an event as time, browser, event, tab ID, window ID,
+// title and URL
+func eventRecord(event types.TabEvent) record {
+	fields := []string{
+		event.Time.Format(time.RFC3339),
+		event.Browser,
+		event.Event,
+		event.TabID,
+		fmt.Sprint(event.WindowID),
+		event.Title,
+		event.URL,
+	}
+	return record{
+		Value:  event,
+		Fields: fields,
+		Line:   strings.Join(fields, delimiter),
+	}
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+var (
+	wordsMatchRegex string
+	wordsJoinWith   string
+)
+
+var wordsCmd = &cobra.Command{
+	Use:   "words [tab_ids...]",
+	Short: "Show sorted unique words from tabs",
+	Long: `Show the sorted unique words of the given tab IDs, or of the active tab
+of every window if none are given. Words are printed one per line, or as a
+JSON array with --format json.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGetWords(args)
+	},
+}
+
+func init() {
+	wordsCmd.Flags().StringVar(&wordsMatchRegex, "match-regex", types.DefaultGetWordsMatchRegex, "JavaScript regular expression matching a word")
+	wordsCmd.Flags().StringVar(&wordsJoinWith, "join-with", types.DefaultGetWordsJoinWith, "separator between words in the output")
+}
+
+func runGetWords(tabIDs []string) error {
+	// Create browser manager
+	bm := newBrowserManager()
+	defer closeBrowserManager(bm)
+
+	// Each tab yields its words joined with the default separator
+	results, err := bm.GetWords(tabIDs, types.WordsOptions{
+		MatchRegex: wordsMatchRegex,
+		JoinWith:   types.DefaultGetWordsJoinWith,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get words: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	words := []string{}
+	for _, result := range results {
+		for _, word := range strings.Split(result, types.DefaultGetWordsJoinWith) {
+			if word != "" && !seen[word] {
+				seen[word] = true
+				words = append(words, word)
+			}
+		}
+	}
+	sort.Strings(words)
+
+	if outputFormat != "tsv" {
+		return FormatStringList("word", words)
+	}
+	if len(words) > 0 {
+		fmt.Println(strings.Join(words, wordsJoinWith))
+	}
+	return nil
+}
@@ -1,30 +1,54 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/tabctl/tabctl/internal/config"
+	"github.com/tabctl/tabctl/internal/dbus"
+	tabctlerrors "github.com/tabctl/tabctl/internal/errors"
+	"github.com/tabctl/tabctl/internal/utils"
 	"github.com/tabctl/tabctl/pkg/api"
 	"github.com/tabctl/tabctl/pkg/types"
 )
 
-// BrowserManager manages multiple D-Bus browser clients
+// ErrNoBrowsers is returned when no browser, or none matching the target,
+// is connected on D-Bus
+var ErrNoBrowsers = errors.New("no browsers found on D-Bus")
+
+// BrowserManager manages multiple D-Bus browser clients. Calls to several
+// browsers are made to all of them at once.
 type BrowserManager struct {
 	clients []api.Client
+
+	mu       sync.Mutex
+	failures []*BrowserError // browsers that failed the last call to several
 }
 
-// NewBrowserManager creates a new manager that discovers all browsers on D-Bus
-func NewBrowserManager(targetBrowser string) *BrowserManager {
+// NewBrowserManager creates a new manager that discovers all browsers on D-Bus.
+// Calls into each mediator are bounded by timeout; zero means no deadline.
+func NewBrowserManager(targetBrowser string, timeout time.Duration) *BrowserManager {
+	return NewBrowserManagerContext(context.Background(), targetBrowser, timeout)
+}
+
+// NewBrowserManagerContext is NewBrowserManager with calls that are aborted
+// when ctx is cancelled
+func NewBrowserManagerContext(ctx context.Context, targetBrowser string, timeout time.Duration) *BrowserManager {
 	mediators := DiscoverMediators()
 	clients := make([]api.Client, 0, len(mediators))
 
 	for _, mediator := range mediators {
 		// Filter by target browser if specified
-		if targetBrowser != "" && !strings.EqualFold(mediator.Browser, targetBrowser) {
+		if targetBrowser != "" && !MatchesBrowser(mediator.Browser, targetBrowser) {
 			continue
 		}
 
-		client, err := NewDBusClient(mediator.Browser)
+		client, err := NewDBusClient(ctx, mediator.Browser, mediator.Prefix, timeout)
 		if err != nil {
 			// Skip failed clients
 			continue
@@ -38,83 +62,468 @@ func NewBrowserManager(targetBrowser string) *BrowserManager {
 	}
 }
 
+// MatchesBrowser reports whether the mediator name is the target. The target
+// is a browser ("Firefox", every profile and instance), a browser profile
+// ("Firefox:work", every instance of it) or an instance ID ("Firefox:work#2").
+func MatchesBrowser(name, target string) bool {
+	if strings.EqualFold(name, target) {
+		return true
+	}
+	if strings.Contains(target, "#") {
+		return false
+	}
+
+	mediator := dbus.ParseBrowserName(name)
+	if strings.Contains(target, ":") {
+		return strings.EqualFold(mediator.Browser+":"+mediator.Profile, target)
+	}
+	return strings.EqualFold(mediator.Browser, target)
+}
+
 // GetClients returns all available clients
 func (bm *BrowserManager) GetClients() []api.Client {
 	return bm.clients
 }
 
+// Failures returns the browsers that failed the last call made to several
+// browsers, in browser order. Such calls return what the other browsers
+// answered and only fail if none did.
+func (bm *BrowserManager) Failures() []*BrowserError {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	return bm.failures
+}
+
+func (bm *BrowserManager) setFailures(failures []*BrowserError) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.failures = failures
+}
+
+// owners returns the clients owning one of tabIDs with their tabs, or every
+// client when tabIDs is empty
+func (bm *BrowserManager) owners(tabIDs []string) ([]api.Client, map[string][]string) {
+	clientTabs := utils.GroupTabsByPrefix(tabIDs)
+	if len(tabIDs) == 0 {
+		return bm.clients, clientTabs
+	}
+
+	var clients []api.Client
+	for _, client := range bm.clients {
+		if _, ok := clientTabs[client.GetPrefix()]; ok {
+			clients = append(clients, client)
+		}
+	}
+	return clients, clientTabs
+}
+
+// merge concatenates the values of the replies in browser order and records
+// the failures. The error is the first failure if no browser answered.
+func merge[T any](bm *BrowserManager, replies []Reply[[]T]) ([]T, error) {
+	failed := failures(replies)
+	bm.setFailures(failed)
+
+	var all []T
+	for _, reply := range replies {
+		all = append(all, reply.Value...)
+	}
+	if len(failed) > 0 && len(failed) == len(replies) {
+		return nil, failed[0]
+	}
+	return all, nil
+}
+
 // ListAllTabs lists tabs from all browsers
 func (bm *BrowserManager) ListAllTabs() ([]types.Tab, error) {
 	if len(bm.clients) == 0 {
-		return nil, fmt.Errorf("no browsers found on D-Bus")
+		return nil, ErrNoBrowsers
 	}
 
-	var allTabs []types.Tab
-	var lastErr error
+	tabs, err := merge(bm, FanOut(bm.clients, api.Client.ListTabs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+	return tabs, nil
+}
 
-	for _, client := range bm.clients {
-		tabs, err := client.ListTabs()
-		if err != nil {
-			lastErr = err
+// CloseTabs closes tabs by ID and returns the outcome for each tab
+func (bm *BrowserManager) CloseTabs(tabIDs []string) ([]types.TabResult, error) {
+	return bm.forEachTab(tabIDs, func(client api.Client, tabIDs []string) error {
+		return client.CloseTabs(tabIDs)
+	})
+}
+
+// MoveTabs moves tabs, routing each move to the browser owning the tab, and
+// returns the outcome for each tab. Moves for the same browser are sent
+// together and applied in order.
+func (bm *BrowserManager) MoveTabs(moves []types.TabMove) ([]types.TabResult, error) {
+	tabIDs := make([]string, len(moves))
+	for i, move := range moves {
+		tabIDs[i] = move.TabID
+	}
+
+	return bm.forEachTab(tabIDs, func(client api.Client, tabIDs []string) error {
+		found := make(map[string]bool, len(tabIDs))
+		for _, tabID := range tabIDs {
+			found[tabID] = true
+		}
+		var clientMoves []types.TabMove
+		for _, move := range moves {
+			if found[move.TabID] {
+				clientMoves = append(clientMoves, move)
+			}
+		}
+		return client.MoveTabs(clientMoves)
+	})
+}
+
+// UpdateTabs updates tab properties, routing each update to the browser
+// owning the tab, and returns the outcome for each tab
+func (bm *BrowserManager) UpdateTabs(updates []types.TabUpdate) ([]types.TabResult, error) {
+	tabIDs := make([]string, len(updates))
+	for i, update := range updates {
+		tabIDs[i] = update.TabID
+	}
+
+	return bm.forEachTab(tabIDs, func(client api.Client, tabIDs []string) error {
+		found := make(map[string]bool, len(tabIDs))
+		for _, tabID := range tabIDs {
+			found[tabID] = true
+		}
+		var clientUpdates []types.TabUpdate
+		for _, update := range updates {
+			if found[update.TabID] {
+				clientUpdates = append(clientUpdates, update)
+			}
+		}
+		return client.UpdateTabs(clientUpdates)
+	})
+}
+
+// forEachTab runs action once per browser, on all browsers at once, with
+// the tabs of tabIDs it owns and returns the outcome for each tab, in the
+// order of tabIDs. Tabs of a browser that is not connected or does not
+// answer are unavailable; see tabOutcomes for the others.
+// The error is ErrNoBrowsers if no browser is connected.
+func (bm *BrowserManager) forEachTab(tabIDs []string, action func(api.Client, []string) error) ([]types.TabResult, error) {
+	if len(bm.clients) == 0 {
+		return nil, ErrNoBrowsers
+	}
+
+	outcomes := make(map[string]types.TabResult, len(tabIDs))
+	var valid []string
+	for _, tabID := range tabIDs {
+		if err := utils.ValidateTabID(tabID); err != nil {
+			outcomes[tabID] = types.TabResult{TabID: tabID, Status: types.TabStatusError, Error: err.Error()}
 			continue
 		}
-		allTabs = append(allTabs, tabs...)
+		valid = append(valid, tabID)
+	}
+	clients, clientTabs := bm.owners(valid)
+	if len(valid) == 0 {
+		clients = nil
 	}
 
-	if len(allTabs) == 0 && lastErr != nil {
-		return nil, fmt.Errorf("failed to list tabs: %w", lastErr)
+	replies := FanOut(clients, func(client api.Client) ([]types.TabResult, error) {
+		return tabOutcomes(client, clientTabs[client.GetPrefix()], action)
+	})
+	for _, reply := range replies {
+		for _, result := range reply.Value {
+			outcomes[result.TabID] = result
+		}
+		delete(clientTabs, reply.Client.GetPrefix())
 	}
+	bm.setFailures(failures(replies))
 
-	return allTabs, nil
+	for prefix, tabs := range clientTabs {
+		for _, tabID := range tabs {
+			outcomes[tabID] = types.TabResult{TabID: tabID, Status: types.TabStatusUnavailable, Error: fmt.Sprintf("no browser with prefix %s", prefix)}
+		}
+	}
+
+	results := make([]types.TabResult, len(tabIDs))
+	for i, tabID := range tabIDs {
+		results[i] = outcomes[tabID]
+	}
+	return results, nil
 }
 
-// CloseTabs closes tabs by ID
-func (bm *BrowserManager) CloseTabs(tabIDs []string) error {
-	if len(bm.clients) == 0 {
-		return fmt.Errorf("no browsers found on D-Bus")
+// tabOutcomes runs action on tabIDs, all owned by client, and returns the
+// outcome for each of them. The browser is only asked for its tabs when the
+// action fails: tabs it does not have are not found, and the action is run
+// once more on the others, which a single unknown tab can make fail. The
+// error is set if the browser did not answer.
+func tabOutcomes(client api.Client, tabIDs []string, action func(api.Client, []string) error) ([]types.TabResult, error) {
+	err := action(client, tabIDs)
+	if err == nil {
+		return tabStatus(tabIDs, types.TabStatusOK, ""), nil
+	}
+	if unanswered(err) {
+		return tabStatus(tabIDs, types.TabStatusUnavailable, err.Error()), err
 	}
 
-	// Group tab IDs by prefix to route to correct browser
-	clientTabs := make(map[string][]string)
+	open, listErr := client.ListTabs()
+	if listErr != nil {
+		return tabStatus(tabIDs, types.TabStatusError, err.Error()), nil
+	}
+	exists := make(map[string]bool, len(open))
+	for _, tab := range open {
+		exists[tab.ID] = true
+	}
+
+	var found, missing []string
 	for _, tabID := range tabIDs {
-		// Extract prefix (e.g., "c." or "f.")
-		parts := strings.SplitN(tabID, ".", 2)
-		if len(parts) > 0 {
-			prefix := parts[0] + "."
-			clientTabs[prefix] = append(clientTabs[prefix], tabID)
+		if exists[tabID] {
+			found = append(found, tabID)
+		} else {
+			missing = append(missing, tabID)
 		}
 	}
+	results := tabStatus(missing, types.TabStatusNotFound, "tab not found")
 
-	var lastErr error
-	for _, client := range bm.clients {
-		prefix := client.GetPrefix()
-		tabs, ok := clientTabs[prefix]
-		if !ok || len(tabs) == 0 {
-			continue
+	if len(missing) > 0 && len(found) > 0 {
+		err = action(client, found)
+	}
+	if err == nil {
+		return append(results, tabStatus(found, types.TabStatusOK, "")...), nil
+	}
+	return append(results, tabStatus(found, types.TabStatusError, err.Error())...), nil
+}
+
+// tabStatus returns the same outcome for each of tabIDs
+func tabStatus(tabIDs []string, status, message string) []types.TabResult {
+	results := make([]types.TabResult, len(tabIDs))
+	for i, tabID := range tabIDs {
+		results[i] = types.TabResult{TabID: tabID, Status: status, Error: message}
+	}
+	return results
+}
+
+// unanswered reports whether err means the browser did not answer in time
+// or the call was cancelled, so asking it anything more is pointless
+func unanswered(err error) bool {
+	var timeout *tabctlerrors.TimeoutError
+	return errors.As(err, &timeout) || errors.Is(err, context.Canceled)
+}
+
+// ResultsError returns an error naming the tabs that did not succeed, or
+// nil if all did
+func ResultsError(results ...types.TabResult) error {
+	var failed []string
+	for _, result := range results {
+		if result.Status != types.TabStatusOK {
+			failed = append(failed, result.TabID+": "+result.Error)
 		}
+	}
+	switch {
+	case len(failed) == 0:
+		return nil
+	case len(results) == 1:
+		return errors.New(failed[0])
+	}
+	return fmt.Errorf("%d of %d tab(s) failed: %s", len(failed), len(results), strings.Join(failed, "; "))
+}
 
-		if err := client.CloseTabs(tabs); err != nil {
-			lastErr = err
+// SelectClient picks the browser for commands that act on a single browser.
+// A non-empty prefix ("f.") selects the browser owning it. Otherwise the only
+// connected browser is used, then preferred if it is connected, and finally
+// the first browser by name so the choice is stable.
+func (bm *BrowserManager) SelectClient(prefix, preferred string) (api.Client, error) {
+	if len(bm.clients) == 0 {
+		return nil, ErrNoBrowsers
+	}
+
+	if prefix != "" {
+		for _, client := range bm.clients {
+			if client.GetPrefix() == prefix {
+				return client, nil
+			}
 		}
+		return nil, fmt.Errorf("no client found for prefix %s", prefix)
+	}
+
+	if len(bm.clients) == 1 {
+		return bm.clients[0], nil
+	}
+
+	if preferred != "" {
+		for _, client := range bm.clients {
+			if strings.EqualFold(client.GetBrowser(), preferred) {
+				return client, nil
+			}
+		}
+	}
+
+	selected := bm.clients[0]
+	for _, client := range bm.clients[1:] {
+		if client.GetBrowser() < selected.GetBrowser() {
+			selected = client
+		}
+	}
+	return selected, nil
+}
+
+// GetText gets the text of the given tabs, or of every loaded tab when
+// tabIDs is empty
+func (bm *BrowserManager) GetText(tabIDs []string, options types.TextOptions) ([]types.TabContent, error) {
+	return bm.getContent(tabIDs, func(client api.Client, tabIDs []string) ([]types.TabContent, error) {
+		return client.GetText(tabIDs, options)
+	})
+}
+
+// GetHTML gets the HTML of the given tabs, or of every loaded tab when
+// tabIDs is empty
+func (bm *BrowserManager) GetHTML(tabIDs []string, options types.TextOptions) ([]types.TabContent, error) {
+	return bm.getContent(tabIDs, func(client api.Client, tabIDs []string) ([]types.TabContent, error) {
+		return client.GetHTML(tabIDs, options)
+	})
+}
+
+// getContent runs fetch on every browser owning one of tabIDs (all browsers
+// when tabIDs is empty) and merges the results
+func (bm *BrowserManager) getContent(tabIDs []string, fetch func(api.Client, []string) ([]types.TabContent, error)) ([]types.TabContent, error) {
+	if len(bm.clients) == 0 {
+		return nil, ErrNoBrowsers
 	}
 
-	return lastErr
+	clients, clientTabs := bm.owners(tabIDs)
+	return merge(bm, FanOut(clients, func(client api.Client) ([]types.TabContent, error) {
+		return fetch(client, clientTabs[client.GetPrefix()])
+	}))
 }
 
-// ActivateTab activates a specific tab
-func (bm *BrowserManager) ActivateTab(tabID string) error {
+// GetWords gets the words of the given tabs, or of the active tab of every
+// window when tabIDs is empty
+func (bm *BrowserManager) GetWords(tabIDs []string, options types.WordsOptions) ([]string, error) {
 	if len(bm.clients) == 0 {
-		return fmt.Errorf("no browsers found on D-Bus")
+		return nil, ErrNoBrowsers
 	}
 
-	// Find the right client based on tab prefix
-	for _, client := range bm.clients {
-		if strings.HasPrefix(tabID, client.GetPrefix()) {
-			return client.ActivateTab(tabID, true)
+	clients, clientTabs := bm.owners(tabIDs)
+	return merge(bm, FanOut(clients, func(client api.Client) ([]string, error) {
+		return client.GetWords(clientTabs[client.GetPrefix()], options)
+	}))
+}
+
+// CaptureTab captures the visible area of a tab, activating it first when it
+// is not the visible tab of its window
+func (bm *BrowserManager) CaptureTab(tabID string) (*types.Screenshot, error) {
+	if err := utils.ValidateTabID(tabID); err != nil {
+		return nil, err
+	}
+
+	client, err := bm.SelectClient(utils.GetTabPrefix(tabID), "")
+	if err != nil {
+		return nil, err
+	}
+
+	tabs, err := client.ListTabs()
+	if err != nil {
+		return nil, err
+	}
+
+	var target *types.Tab
+	for i := range tabs {
+		if tabs[i].ID == tabID {
+			target = &tabs[i]
+			break
 		}
 	}
+	if target == nil {
+		return nil, fmt.Errorf("tab %s not found", tabID)
+	}
 
-	return fmt.Errorf("no client found for tab %s", tabID)
+	if !target.Active {
+		if err := client.ActivateTab(tabID, true); err != nil {
+			return nil, fmt.Errorf("failed to activate tab: %w", err)
+		}
+		time.Sleep(config.ScreenshotSettleDelay)
+	}
+
+	return client.GetScreenshot(fmt.Sprintf("%s%d", client.GetPrefix(), target.WindowID))
+}
+
+// CaptureWindows captures the active tab of every window of every browser,
+// ordered by window ID within each browser
+func (bm *BrowserManager) CaptureWindows() ([]types.Screenshot, error) {
+	if len(bm.clients) == 0 {
+		return nil, ErrNoBrowsers
+	}
+
+	return merge(bm, FanOut(bm.clients, captureWindows))
+}
+
+// captureWindows captures the active tab of every window of a browser. It
+// only fails if no window could be captured.
+func captureWindows(client api.Client) ([]types.Screenshot, error) {
+	tabs, err := client.ListTabs()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	var windowIDs []int
+	for _, tab := range tabs {
+		if !seen[tab.WindowID] {
+			seen[tab.WindowID] = true
+			windowIDs = append(windowIDs, tab.WindowID)
+		}
+	}
+	sort.Ints(windowIDs)
+
+	var screenshots []types.Screenshot
+	var lastErr error
+	for _, windowID := range windowIDs {
+		// Minimized windows cannot be captured; keep going with the rest
+		screenshot, err := client.GetScreenshot(fmt.Sprintf("%s%d", client.GetPrefix(), windowID))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		screenshots = append(screenshots, *screenshot)
+	}
+
+	if len(screenshots) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return screenshots, nil
+}
+
+// RemoveDuplicates finds tabs showing the same normalized URL across all
+// browsers and closes all but one per group, unless dryRun is set. It
+// returns the groups found.
+func (bm *BrowserManager) RemoveDuplicates(dryRun bool) ([]types.DuplicateGroup, error) {
+	tabs, err := bm.ListAllTabs()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := FindDuplicates(tabs)
+	if dryRun {
+		return groups, nil
+	}
+
+	if tabIDs := duplicateIDs(groups); len(tabIDs) > 0 {
+		results, err := bm.CloseTabs(tabIDs)
+		if err == nil {
+			err = ResultsError(results...)
+		}
+		if err != nil {
+			return groups, fmt.Errorf("failed to close duplicate tabs: %w", err)
+		}
+	}
+	return groups, nil
+}
+
+// ActivateTab activates a specific tab and returns its outcome
+func (bm *BrowserManager) ActivateTab(tabID string) (types.TabResult, error) {
+	results, err := bm.forEachTab([]string{tabID}, func(client api.Client, tabIDs []string) error {
+		return client.ActivateTab(tabIDs[0], true)
+	})
+	if err != nil {
+		return types.TabResult{}, err
+	}
+	return results[0], nil
 }
 
 // Close closes all clients
@@ -1,12 +1,18 @@
 package client
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	godbus "github.com/godbus/dbus/v5"
 	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/internal/utils"
 	"github.com/tabctl/tabctl/pkg/api"
 	"github.com/tabctl/tabctl/pkg/types"
 )
@@ -18,15 +24,21 @@ type DBusClient struct {
 	prefix  string
 }
 
-// NewDBusClient creates a new D-Bus client for a specific browser
-func NewDBusClient(browser string) (api.Client, error) {
+// NewDBusClient creates a new D-Bus client for a specific browser. Tab IDs
+// use prefix ("b."), or the browser's default prefix when it is empty.
+// Each D-Bus call is bounded by timeout; zero means no deadline. Cancelling
+// ctx aborts the calls in flight.
+func NewDBusClient(ctx context.Context, browser, prefix string, timeout time.Duration) (api.Client, error) {
 	dbusClient, err := dbus.NewClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create D-Bus client: %w", err)
 	}
+	dbusClient.SetContext(ctx)
+	dbusClient.SetTimeout(timeout)
 
-	// Determine prefix based on browser
-	prefix := determinePrefixForBrowser(browser)
+	if prefix == "" {
+		prefix = AssignPrefixes([]string{browser})[browser]
+	}
 
 	return &DBusClient{
 		client:  dbusClient,
@@ -35,17 +47,6 @@ func NewDBusClient(browser string) (api.Client, error) {
 	}, nil
 }
 
-func determinePrefixForBrowser(browser string) string {
-	switch strings.ToLower(browser) {
-	case "firefox":
-		return "f."
-	case "chrome", "chromium", "brave":
-		return "c."
-	default:
-		return "u." // unknown
-	}
-}
-
 // GetPrefix returns the client prefix
 func (c *DBusClient) GetPrefix() string {
 	return c.prefix
@@ -81,19 +82,33 @@ func (c *DBusClient) ListTabs() ([]types.Tab, error) {
 		return nil, fmt.Errorf("failed to list tabs via D-Bus: %w", err)
 	}
 
+	return c.convertTabInfos(tabInfos), nil
+}
+
+// localIDs rewrites IDs reported by the mediator to this client's prefix
+func (c *DBusClient) localIDs(ids []string) []string {
+	for i, id := range ids {
+		ids[i] = rewritePrefix(id, c.prefix)
+	}
+	return ids
+}
+
+// convertTabInfos converts D-Bus tab info into API tabs
+func (c *DBusClient) convertTabInfos(tabInfos []dbus.TabInfo) []types.Tab {
 	tabs := make([]types.Tab, len(tabInfos))
 	for i, info := range tabInfos {
+		windowID, _ := strconv.Atoi(utils.GetWindowID(info.ID))
 		tabs[i] = types.Tab{
-			ID:     info.ID,
-			Title:  info.Title,
-			URL:    info.URL,
-			Index:  int(info.Index),
-			Active: info.Active,
-			Pinned: info.Pinned,
+			ID:       rewritePrefix(info.ID, c.prefix),
+			Title:    info.Title,
+			URL:      info.URL,
+			WindowID: windowID,
+			Index:    int(info.Index),
+			Active:   info.Active,
+			Pinned:   info.Pinned,
 		}
 	}
-
-	return tabs, nil
+	return tabs
 }
 
 // CloseTabs closes the specified tabs
@@ -108,34 +123,67 @@ func (c *DBusClient) ActivateTab(tabID string, focused bool) error {
 	return c.client.ActivateTab(c.browser, tabID)
 }
 
-// MoveTabs moves tabs (not implemented)
-func (c *DBusClient) MoveTabs() error {
-	return errors.New("MoveTabs not implemented for D-Bus client")
+// MoveTabs moves tabs between windows and positions, in the given order
+func (c *DBusClient) MoveTabs(moves []types.TabMove) error {
+	dbusMoves := make([]dbus.TabMove, len(moves))
+	for i, move := range moves {
+		dbusMoves[i] = dbus.TabMove{
+			TabID:    move.TabID,
+			WindowID: int32(move.WindowID),
+			Index:    int32(move.Index),
+		}
+	}
+
+	if err := c.client.MoveTabs(c.browser, dbusMoves); err != nil {
+		return fmt.Errorf("failed to move tabs via D-Bus: %w", err)
+	}
+	return nil
 }
 
-// UpdateTabs updates tabs with the given properties
+// UpdateTabs updates tabs with the given properties (url, active, pinned, muted, ...)
 func (c *DBusClient) UpdateTabs(updates []types.TabUpdate) error {
-	// For now, handle URL updates and properties
+	dbusUpdates := make([]dbus.TabUpdate, 0, len(updates))
 	for _, update := range updates {
-		if update.URL != "" {
-			// Would need a new D-Bus method to update URL
-			continue
+		properties := make(map[string]godbus.Variant, len(update.Properties)+1)
+		for key, value := range update.Properties {
+			properties[key] = godbus.MakeVariant(value)
 		}
-		// Check properties for active state
-		if update.Properties != nil {
-			if active, ok := update.Properties["active"].(bool); ok && active {
-				if err := c.ActivateTab(update.TabID, true); err != nil {
-					return err
-				}
-			}
+		if update.URL != "" {
+			properties["url"] = godbus.MakeVariant(update.URL)
 		}
+		dbusUpdates = append(dbusUpdates, dbus.TabUpdate{
+			TabID:      update.TabID,
+			Properties: properties,
+		})
+	}
+
+	updated, err := c.client.UpdateTabs(c.browser, dbusUpdates)
+	if err != nil {
+		return err
+	}
+	// Browsers only return the tabs they could update
+	if len(updated) < len(updates) {
+		return fmt.Errorf("%d of %d tab(s) not updated", len(updates)-len(updated), len(updates))
 	}
 	return nil
 }
 
-// QueryTabs filters tabs based on a query
+// QueryTabs filters tabs based on a query, evaluated by the browser's tabs.query
 func (c *DBusClient) QueryTabs(query types.TabQuery) ([]types.Tab, error) {
-	// Get all tabs first
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query: %w", err)
+	}
+
+	tabInfos, err := c.client.QueryTabs(c.browser, string(queryJSON))
+	if err == nil {
+		return c.convertTabInfos(tabInfos), nil
+	}
+	if !dbus.IsUnknownMethod(err) {
+		return nil, fmt.Errorf("failed to query tabs via D-Bus: %w", err)
+	}
+
+	// Mediators started before QueryTabs existed: filter the full list locally
 	tabs, err := c.ListTabs()
 	if err != nil {
 		return nil, err
@@ -181,23 +229,91 @@ func matchesQuery(tab types.Tab, query types.TabQuery) bool {
 
 // NavigateURLs navigates tabs to new URLs
 func (c *DBusClient) NavigateURLs(pairs []types.TabURLPair) error {
-	// This would require a new D-Bus method
-	return errors.New("NavigateURLs not implemented for D-Bus client")
+	updates := make([]types.TabUpdate, len(pairs))
+	for i, pair := range pairs {
+		updates[i] = types.TabUpdate{TabID: pair.TabID, URL: pair.URL}
+	}
+	return c.UpdateTabs(updates)
 }
 
-// GetText gets text content from tabs
+// GetText gets text content from tabs. Empty tabIDs means every loaded tab.
 func (c *DBusClient) GetText(tabIDs []string, options types.TextOptions) ([]types.TabContent, error) {
-	return nil, errors.New("GetText not implemented for D-Bus client")
+	if options.DelimiterRegex == "" {
+		options.DelimiterRegex = types.DefaultGetTextDelimiterRegex
+		options.ReplaceWith = types.DefaultGetTextReplaceWith
+	}
+
+	content, err := c.client.GetText(c.browser, options.DelimiterRegex, options.ReplaceWith)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get text via D-Bus: %w", err)
+	}
+	return c.filterContent(content, tabIDs, options.Cleanup), nil
 }
 
-// GetHTML gets HTML content from tabs
+// GetHTML gets HTML content from tabs. Empty tabIDs means every loaded tab.
 func (c *DBusClient) GetHTML(tabIDs []string, options types.TextOptions) ([]types.TabContent, error) {
-	return nil, errors.New("GetHTML not implemented for D-Bus client")
+	if options.DelimiterRegex == "" {
+		options.DelimiterRegex = types.DefaultGetHTMLDelimiterRegex
+		options.ReplaceWith = types.DefaultGetHTMLReplaceWith
+	}
+
+	content, err := c.client.GetHTML(c.browser, options.DelimiterRegex, options.ReplaceWith)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HTML via D-Bus: %w", err)
+	}
+	return c.filterContent(content, tabIDs, options.Cleanup), nil
+}
+
+// filterContent keeps the requested tabs (all when tabIDs is empty) and
+// optionally collapses runs of whitespace
+func (c *DBusClient) filterContent(content []dbus.TabContent, tabIDs []string, cleanup bool) []types.TabContent {
+	wanted := make(map[string]bool, len(tabIDs))
+	for _, tabID := range tabIDs {
+		wanted[tabID] = true
+	}
+
+	result := make([]types.TabContent, 0, len(content))
+	for _, item := range content {
+		tabID := rewritePrefix(item.ID, c.prefix)
+		if len(wanted) > 0 && !wanted[tabID] {
+			continue
+		}
+		text := item.Content
+		if cleanup {
+			text = strings.Join(strings.Fields(text), " ")
+		}
+		result = append(result, types.TabContent{
+			TabID:   tabID,
+			Title:   item.Title,
+			URL:     item.URL,
+			Content: text,
+		})
+	}
+	return result
 }
 
-// GetWords gets words from tabs
+// GetWords gets words from tabs. Empty tabIDs means the active tab of every window.
 func (c *DBusClient) GetWords(tabIDs []string, options types.WordsOptions) ([]string, error) {
-	return nil, errors.New("GetWords not implemented for D-Bus client")
+	if options.MatchRegex == "" {
+		options.MatchRegex = types.DefaultGetWordsMatchRegex
+	}
+	if options.JoinWith == "" {
+		options.JoinWith = types.DefaultGetWordsJoinWith
+	}
+
+	if len(tabIDs) == 0 {
+		tabIDs = []string{""}
+	}
+
+	var words []string
+	for _, tabID := range tabIDs {
+		tabWords, err := c.client.GetWords(c.browser, tabID, options.MatchRegex, options.JoinWith)
+		if err != nil {
+			return words, fmt.Errorf("failed to get words via D-Bus: %w", err)
+		}
+		words = append(words, tabWords...)
+	}
+	return words, nil
 }
 
 // GetWindows returns all windows
@@ -250,44 +366,101 @@ func (c *DBusClient) GetActiveTab() (string, error) {
 
 // GetActiveTabs returns all active tabs (one per window)
 func (c *DBusClient) GetActiveTabs() ([]string, error) {
-	tabs, err := c.ListTabs()
+	tabIDs, err := c.client.GetActiveTabs(c.browser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active tabs via D-Bus: %w", err)
+	}
+	return c.localIDs(tabIDs), nil
+}
+
+// OpenURLs opens new tabs with the given URLs. windowID is "" for the
+// current window, "<prefix>.<window>" for an existing window, or
+// "<prefix>.0" for a new window.
+func (c *DBusClient) OpenURLs(urls []string, windowID string, options types.OpenOptions) ([]string, error) {
+	window, err := parseWindowID(windowID)
 	if err != nil {
 		return nil, err
 	}
 
-	var activeTabs []string
-	for _, tab := range tabs {
-		if tab.Active {
-			activeTabs = append(activeTabs, tab.ID)
-		}
+	dbusOptions := map[string]godbus.Variant{
+		"active": godbus.MakeVariant(!options.Background),
+		"pinned": godbus.MakeVariant(options.Pinned),
 	}
 
-	return activeTabs, nil
+	tabIDs, err := c.client.OpenURLs(c.browser, urls, window, dbusOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open URLs via D-Bus: %w", err)
+	}
+	return c.localIDs(tabIDs), nil
 }
 
-// OpenURLs opens new tabs with the given URLs
-func (c *DBusClient) OpenURLs(urls []string, windowID string) ([]string, error) {
-	var tabIDs []string
-
-	for _, url := range urls {
-		tabID, err := c.client.OpenTab(c.browser, url)
-		if err != nil {
-			return tabIDs, fmt.Errorf("failed to open URL %s: %w", url, err)
-		}
-		tabIDs = append(tabIDs, tabID)
+// RemoveDuplicates closes the duplicate tabs of this browser, keeping one
+// tab per normalized URL (see FindDuplicates)
+func (c *DBusClient) RemoveDuplicates() error {
+	tabs, err := c.ListTabs()
+	if err != nil {
+		return err
 	}
 
-	return tabIDs, nil
+	tabIDs := duplicateIDs(FindDuplicates(tabs))
+	if len(tabIDs) == 0 {
+		return nil
+	}
+	return c.CloseTabs(tabIDs)
 }
 
-// RemoveDuplicates removes duplicate tabs
-func (c *DBusClient) RemoveDuplicates() error {
-	return errors.New("RemoveDuplicates not implemented for D-Bus client")
+// parseWindowID converts "<prefix>.<window>" into the numeric window ID, or
+// dbus.WindowCurrent when windowID names no window
+func parseWindowID(windowID string) (int32, error) {
+	if windowID == "" {
+		return dbus.WindowCurrent, nil
+	}
+
+	_, windowStr, err := utils.ParsePrefixAndWindowID(windowID)
+	if err != nil {
+		return 0, err
+	}
+	if windowStr == "" {
+		return dbus.WindowCurrent, nil
+	}
+
+	num, err := strconv.Atoi(windowStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid window ID: %s", windowID)
+	}
+	return int32(num), nil
 }
 
-// GetScreenshot gets a screenshot (not implemented for D-Bus)
-func (c *DBusClient) GetScreenshot() (*types.Screenshot, error) {
-	return nil, errors.New("GetScreenshot not implemented for D-Bus client")
+// GetScreenshot captures the visible area of the active tab in a window, or
+// in the last focused window when windowID is ""
+func (c *DBusClient) GetScreenshot(windowID string) (*types.Screenshot, error) {
+	window, err := parseWindowID(windowID)
+	if err != nil {
+		return nil, err
+	}
+
+	shot, err := c.client.GetScreenshot(c.browser, window)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get screenshot via D-Bus: %w", err)
+	}
+
+	// The extension returns a data URL: "data:image/png;base64,<payload>"
+	comma := strings.IndexByte(shot.Data, ',')
+	if comma < 0 || !strings.HasSuffix(shot.Data[:comma], ";base64") {
+		return nil, errors.New("screenshot is not a base64 data URL")
+	}
+	data, err := base64.StdEncoding.DecodeString(shot.Data[comma+1:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+
+	prefix := strings.TrimSuffix(c.prefix, ".")
+	return &types.Screenshot{
+		Data:     data,
+		TabID:    fmt.Sprintf("%s.%d.%d", prefix, shot.WindowID, shot.TabID),
+		WindowID: fmt.Sprintf("%s.%d", prefix, shot.WindowID),
+		API:      c.prefix,
+	}, nil
 }
 
 // GetClient returns the underlying D-Bus client (for testing)
@@ -296,7 +469,7 @@ func (c *DBusClient) GetClient() *dbus.Client {
 }
 
 // DiscoverDBusBrowsers discovers all browsers available on D-Bus
-func DiscoverDBusBrowsers() ([]string, error) {
+func DiscoverDBusBrowsers() ([]dbus.BrowserInstance, error) {
 	client, err := dbus.NewClient()
 	if err != nil {
 		return nil, err
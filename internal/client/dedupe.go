@@ -0,0 +1,109 @@
+package client
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// trackingParams are query parameters that do not change the page shown;
+// parameters starting with utm_ are dropped as well
+var trackingParams = map[string]bool{
+	"fbclid": true,
+	"gclid":  true,
+	"mc_cid": true,
+	"mc_eid": true,
+}
+
+// NormalizeURL returns the form of a URL used to detect duplicate tabs: no
+// fragment, no tracking parameters, no trailing slash, lowercase scheme and
+// host. URLs that fail to parse are returned unchanged.
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for param := range query {
+			name := strings.ToLower(param)
+			if strings.HasPrefix(name, "utm_") || trackingParams[name] {
+				query.Del(param)
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
+
+// FindDuplicates groups tabs by normalized URL. In every group with more
+// than one tab, a pinned tab is kept over others, then an active one, then
+// the leftmost. Groups are ordered by the tab kept.
+func FindDuplicates(tabs []types.Tab) []types.DuplicateGroup {
+	byURL := make(map[string][]types.Tab)
+	var order []string
+	for _, tab := range tabs {
+		if tab.URL == "" {
+			continue
+		}
+		key := NormalizeURL(tab.URL)
+		if _, ok := byURL[key]; !ok {
+			order = append(order, key)
+		}
+		byURL[key] = append(byURL[key], tab)
+	}
+
+	var groups []types.DuplicateGroup
+	for _, key := range order {
+		group := byURL[key]
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.SliceStable(group, func(i, j int) bool { return keepBefore(group[i], group[j]) })
+		groups = append(groups, types.DuplicateGroup{
+			URL:   key,
+			Keep:  group[0],
+			Close: group[1:],
+		})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Keep.ID < groups[j].Keep.ID })
+	return groups
+}
+
+// keepBefore reports whether a is a better tab to keep than b
+func keepBefore(a, b types.Tab) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	if a.Active != b.Active {
+		return a.Active
+	}
+	if a.Index != b.Index {
+		return a.Index < b.Index
+	}
+	return a.ID < b.ID
+}
+
+// duplicateIDs returns the IDs of the tabs to close in groups
+func duplicateIDs(groups []types.DuplicateGroup) []string {
+	var tabIDs []string
+	for _, group := range groups {
+		for _, tab := range group.Close {
+			tabIDs = append(tabIDs, tab.ID)
+		}
+	}
+	return tabIDs
+}
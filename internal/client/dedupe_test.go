@@ -0,0 +1,181 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"unchanged", "https://example.com/a", "https://example.com/a"},
+		{"fragment", "https://example.com/a#section", "https://example.com/a"},
+		{"empty fragment", "https://example.com/a#", "https://example.com/a"},
+		{"trailing slash", "https://example.com/a/", "https://example.com/a"},
+		{"trailing slashes", "https://example.com/a//", "https://example.com/a"},
+		{"root slash", "https://example.com/", "https://example.com"},
+		{"host case", "https://Example.COM/a", "https://example.com/a"},
+		{"scheme case", "HTTPS://example.com/a", "https://example.com/a"},
+		{"path case kept", "https://example.com/ReadMe", "https://example.com/ReadMe"},
+		{"utm params", "https://example.com/a?utm_source=x&utm_medium=y&id=1", "https://example.com/a?id=1"},
+		{"utm params any case", "https://example.com/a?UTM_Campaign=x&id=1", "https://example.com/a?id=1"},
+		{"fbclid", "https://example.com/a?fbclid=abc", "https://example.com/a"},
+		{"gclid and mc params", "https://example.com/a?gclid=1&mc_cid=2&mc_eid=3&q=go", "https://example.com/a?q=go"},
+		{"query order", "https://example.com/a?b=2&a=1", "https://example.com/a?a=1&b=2"},
+		{"query values kept", "https://example.com/a?q=one&q=two", "https://example.com/a?q=one&q=two"},
+		{"all together", "HTTPS://Example.com/a/?utm_source=x&b=2&a=1#top", "https://example.com/a?a=1&b=2"},
+		{"not a utm prefix", "https://example.com/a?utmost=1", "https://example.com/a?utmost=1"},
+		{"about page", "about:newtab", "about:newtab"},
+		{"unparsable", "http://[::1", "http://[::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeURL(tt.url); got != tt.want {
+				t.Errorf("NormalizeURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLDuplicates(t *testing.T) {
+	same := []string{
+		"https://example.com/page",
+		"https://example.com/page/",
+		"https://EXAMPLE.com/page#intro",
+		"https://example.com/page?utm_source=newsletter",
+		"https://example.com/page/?fbclid=x#y",
+	}
+	for _, url := range same[1:] {
+		if NormalizeURL(url) != NormalizeURL(same[0]) {
+			t.Errorf("NormalizeURL(%q) = %q, want %q", url, NormalizeURL(url), NormalizeURL(same[0]))
+		}
+	}
+
+	different := []string{
+		"http://example.com/page",
+		"https://example.com/page?id=2",
+		"https://example.com/Page",
+		"https://www.example.com/page",
+	}
+	for _, url := range different {
+		if NormalizeURL(url) == NormalizeURL(same[0]) {
+			t.Errorf("NormalizeURL(%q) matches %q", url, same[0])
+		}
+	}
+}
+
+func TestFindDuplicatesKeepOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		tabs  []types.Tab
+		keep  string
+		close []string
+	}{
+		{
+			name: "leftmost",
+			tabs: []types.Tab{
+				{ID: "f.1.3", URL: "https://a.example/", Index: 2},
+				{ID: "f.1.1", URL: "https://a.example", Index: 0},
+				{ID: "f.1.2", URL: "https://a.example#x", Index: 1},
+			},
+			keep:  "f.1.1",
+			close: []string{"f.1.2", "f.1.3"},
+		},
+		{
+			name: "active over leftmost",
+			tabs: []types.Tab{
+				{ID: "f.1.1", URL: "https://a.example", Index: 0},
+				{ID: "f.1.2", URL: "https://a.example", Index: 1, Active: true},
+			},
+			keep:  "f.1.2",
+			close: []string{"f.1.1"},
+		},
+		{
+			name: "pinned over active",
+			tabs: []types.Tab{
+				{ID: "f.1.1", URL: "https://a.example", Index: 0, Active: true},
+				{ID: "f.1.5", URL: "https://a.example", Index: 4, Pinned: true},
+				{ID: "f.1.2", URL: "https://a.example", Index: 1},
+			},
+			keep:  "f.1.5",
+			close: []string{"f.1.1", "f.1.2"},
+		},
+		{
+			name: "leftmost pinned",
+			tabs: []types.Tab{
+				{ID: "f.1.7", URL: "https://a.example", Index: 3, Pinned: true},
+				{ID: "f.1.6", URL: "https://a.example", Index: 2, Pinned: true, Active: true},
+				{ID: "f.1.5", URL: "https://a.example", Index: 1, Pinned: true},
+			},
+			keep:  "f.1.6",
+			close: []string{"f.1.5", "f.1.7"},
+		},
+		{
+			name: "same index in two windows",
+			tabs: []types.Tab{
+				{ID: "f.2.9", URL: "https://a.example", Index: 0},
+				{ID: "f.1.4", URL: "https://a.example", Index: 0},
+			},
+			keep:  "f.1.4",
+			close: []string{"f.2.9"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := FindDuplicates(tt.tabs)
+			if len(groups) != 1 {
+				t.Fatalf("FindDuplicates returned %d groups, want 1", len(groups))
+			}
+			if groups[0].Keep.ID != tt.keep {
+				t.Errorf("keep = %s, want %s", groups[0].Keep.ID, tt.keep)
+			}
+			var closeIDs []string
+			for _, tab := range groups[0].Close {
+				closeIDs = append(closeIDs, tab.ID)
+			}
+			if !reflect.DeepEqual(closeIDs, tt.close) {
+				t.Errorf("close = %v, want %v", closeIDs, tt.close)
+			}
+		})
+	}
+}
+
+func TestFindDuplicatesGroups(t *testing.T) {
+	tabs := []types.Tab{
+		{ID: "z.3.1", URL: "https://b.example/?utm_medium=mail", Index: 0},
+		{ID: "f.1.1", URL: "https://a.example", Index: 0},
+		{ID: "f.1.2", URL: "https://unique.example", Index: 1},
+		{ID: "f.1.3", URL: "https://b.example", Index: 2},
+		{ID: "f.1.4", URL: "https://a.example/#top", Index: 3},
+		{ID: "f.1.5", URL: "", Index: 4},
+		{ID: "f.1.6", URL: "", Index: 5},
+	}
+
+	groups := FindDuplicates(tabs)
+
+	// Tabs without a URL are never duplicates; groups are ordered by the
+	// tab kept
+	want := []struct{ url, keep string }{
+		{"https://a.example", "f.1.1"},
+		{"https://b.example", "z.3.1"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("FindDuplicates returned %d groups, want %d: %+v", len(groups), len(want), groups)
+	}
+	for i, w := range want {
+		if groups[i].URL != w.url || groups[i].Keep.ID != w.keep {
+			t.Errorf("group %d = %s keeping %s, want %s keeping %s", i, groups[i].URL, groups[i].Keep.ID, w.url, w.keep)
+		}
+	}
+
+	if ids := duplicateIDs(groups); !reflect.DeepEqual(ids, []string{"f.1.4", "f.1.3"}) {
+		t.Errorf("duplicateIDs = %v, want [f.1.4 f.1.3]", ids)
+	}
+}
@@ -6,15 +6,15 @@ import (
 
 // MediatorInfo represents information about a discovered mediator
 type MediatorInfo struct {
-	Browser string
-	Prefix  string
+	Browser  string // unique mediator name: "Firefox", "Firefox:work#2"
+	Prefix   string
+	Instance dbus.BrowserInstance
 }
 
 // DiscoverMediators discovers all available D-Bus mediators
 func DiscoverMediators() []MediatorInfo {
 	var mediators []MediatorInfo
 
-	// Create D-Bus client
 	client, err := dbus.NewClient()
 	if err != nil {
 		return mediators
@@ -22,18 +22,18 @@ func DiscoverMediators() []MediatorInfo {
 	defer client.Close()
 
 	// Discover browsers on D-Bus
-	browsers, err := client.DiscoverBrowsers()
+	instances, err := client.DiscoverBrowsers()
 	if err != nil {
 		return mediators
 	}
 
-	// Create MediatorInfo for each browser
-	for _, browser := range browsers {
-		prefix := determineBrowserPrefix(browser)
-
+	// Create MediatorInfo for each browser, with prefixes unique among them
+	prefixes := AssignPrefixes(instanceNames(instances))
+	for _, instance := range instances {
 		mediator := MediatorInfo{
-			Browser: browser,
-			Prefix:  prefix,
+			Browser:  instance.Name,
+			Prefix:   prefixes[instance.Name],
+			Instance: instance,
 		}
 
 		mediators = append(mediators, mediator)
@@ -42,13 +42,11 @@ func DiscoverMediators() []MediatorInfo {
 	return mediators
 }
 
-func determineBrowserPrefix(browser string) string {
-	switch browser {
-	case "Firefox":
-		return "f."
-	case "Chrome", "Chromium", "Brave":
-		return "c."
-	default:
-		return "u."
+// instanceNames returns the mediator names of the instances
+func instanceNames(instances []dbus.BrowserInstance) []string {
+	names := make([]string, len(instances))
+	for i, instance := range instances {
+		names[i] = instance.Name
 	}
+	return names
 }
\ No newline at end of file
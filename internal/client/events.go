@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// signalEvents maps D-Bus signal names to tab event names
+var signalEvents = map[string]string{
+	dbus.SignalTabCreated:          types.EventCreated,
+	dbus.SignalTabRemoved:          types.EventRemoved,
+	dbus.SignalTabUpdated:          types.EventUpdated,
+	dbus.SignalTabActivated:        types.EventActivated,
+	dbus.SignalTabMoved:            types.EventMoved,
+	dbus.SignalWindowFocused:       types.EventFocused,
+	dbus.SignalBrowserConnected:    types.EventConnected,
+	dbus.SignalBrowserDisconnected: types.EventDisconnected,
+}
+
+// WatchEvents streams tab events from every mediator on D-Bus until ctx is
+// done, including mediators that connect mid-stream. Events that only carry a
+// tab ID are completed with the last known title and URL of that tab, and tab
+// IDs use the prefixes from AssignPrefixes.
+// The tab list of each mediator is seeded in the background, bounded by
+// timeout, so a mediator that does not answer holds up no events.
+func WatchEvents(ctx context.Context, timeout time.Duration) (<-chan types.TabEvent, error) {
+	dbusClient, err := dbus.NewClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create D-Bus client: %w", err)
+	}
+	dbusClient.SetTimeout(timeout)
+
+	// Subscribe before seeding so no change slips through in between
+	raw, err := dbusClient.WatchEvents(ctx)
+	if err != nil {
+		dbusClient.Close()
+		return nil, err
+	}
+
+	// Seeds are merged by the loop below, which owns the cache. A failed
+	// seed is sent without tabs so the cache stops waiting for it.
+	cache := newTabCache()
+	seeds := make(chan seedResult)
+	seed := func(browser string) {
+		cache.seeding[browser] = true
+		go func() {
+			tabs, _ := dbusClient.ListTabs(browser)
+			select {
+			case seeds <- seedResult{browser: browser, tabs: tabs}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if instances, err := dbusClient.DiscoverBrowsers(); err == nil {
+		cache.browsers = instanceNames(instances)
+		cache.prefixes = AssignPrefixes(cache.browsers)
+		for _, browser := range cache.browsers {
+			seed(browser)
+		}
+	}
+
+	events := make(chan types.TabEvent, 64)
+	go func() {
+		defer close(events)
+		defer dbusClient.Close()
+
+		for {
+			var rawEvent dbus.Event
+			select {
+			case result := <-seeds:
+				cache.seed(result.browser, result.tabs)
+				continue
+			case event, ok := <-raw:
+				if !ok {
+					return
+				}
+				rawEvent = event
+			}
+
+			if rawEvent.Signal == dbus.SignalBrowserConnected {
+				cache.connect(rawEvent.Browser)
+				seed(rawEvent.Browser)
+			}
+
+			event, ok := cache.apply(rawEvent)
+			if !ok {
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// seedResult is the tab list of a browser, read to seed the cache
+type seedResult struct {
+	browser string
+	tabs    []dbus.TabInfo
+}
+
+// tabCache remembers the last known state of every tab, keyed by browser and
+// numeric tab ID so it survives moves between windows
+type tabCache struct {
+	tabs     map[string]types.Tab
+	browsers []string
+	prefixes map[string]string // browser -> tab ID prefix
+
+	// Browsers whose tab list is being read, and the tabs removed since, which
+	// the list may still contain
+	seeding map[string]bool
+	removed map[string]bool
+}
+
+func newTabCache() *tabCache {
+	return &tabCache{
+		tabs:    make(map[string]types.Tab),
+		seeding: make(map[string]bool),
+		removed: make(map[string]bool),
+	}
+}
+
+// connect registers a browser that joined the bus
+func (c *tabCache) connect(browser string) {
+	for _, known := range c.browsers {
+		if known == browser {
+			return
+		}
+	}
+	c.browsers = append(c.browsers, browser)
+	c.prefixes = AssignPrefixes(c.browsers)
+}
+
+// localID rewrites a tab ID reported by a mediator to its browser's prefix
+func (c *tabCache) localID(browser, tabID string) string {
+	if prefix, ok := c.prefixes[browser]; ok {
+		return rewritePrefix(tabID, prefix)
+	}
+	return tabID
+}
+
+// cacheKey returns the window-independent key for a tab ID
+func cacheKey(browser, tabID string) string {
+	return browser + "/" + tabID[strings.LastIndex(tabID, ".")+1:]
+}
+
+// seed loads the tabs of a browser listed when it connected. Tab events
+// reported since are newer: tabs created or changed since are kept, and tabs
+// removed since stay out.
+func (c *tabCache) seed(browser string, tabs []dbus.TabInfo) {
+	for _, info := range tabs {
+		key := cacheKey(browser, info.ID)
+		if _, ok := c.tabs[key]; !ok && !c.removed[key] {
+			c.store(browser, info)
+		}
+	}
+
+	delete(c.seeding, browser)
+	c.forgetRemoved(browser)
+}
+
+// forgetRemoved drops the tabs of browser recorded as removed
+func (c *tabCache) forgetRemoved(browser string) {
+	for key := range c.removed {
+		if strings.HasPrefix(key, browser+"/") {
+			delete(c.removed, key)
+		}
+	}
+}
+
+func (c *tabCache) store(browser string, info dbus.TabInfo) {
+	windowID, _ := strconv.Atoi(utils.GetWindowID(info.ID))
+	c.tabs[cacheKey(browser, info.ID)] = types.Tab{
+		ID:       c.localID(browser, info.ID),
+		Title:    info.Title,
+		URL:      info.URL,
+		WindowID: windowID,
+		Index:    int(info.Index),
+		Active:   info.Active,
+		Pinned:   info.Pinned,
+	}
+}
+
+// apply updates the cache with a raw event and returns the completed tab event
+func (c *tabCache) apply(raw dbus.Event) (types.TabEvent, bool) {
+	name, ok := signalEvents[raw.Signal]
+	if !ok {
+		return types.TabEvent{}, false
+	}
+
+	event := types.TabEvent{
+		Time:     time.Now(),
+		Browser:  raw.Browser,
+		Event:    name,
+		TabID:    c.localID(raw.Browser, raw.TabID),
+		WindowID: int(raw.WindowID),
+	}
+
+	switch raw.Signal {
+	case dbus.SignalTabCreated, dbus.SignalTabUpdated:
+		c.store(raw.Browser, *raw.Tab)
+	case dbus.SignalBrowserDisconnected:
+		for key := range c.tabs {
+			if strings.HasPrefix(key, raw.Browser+"/") {
+				delete(c.tabs, key)
+			}
+		}
+		c.forgetRemoved(raw.Browser)
+		return event, true
+	case dbus.SignalWindowFocused, dbus.SignalBrowserConnected:
+		return event, true
+	}
+
+	key := cacheKey(raw.Browser, raw.TabID)
+	tab, known := c.tabs[key]
+
+	switch raw.Signal {
+	case dbus.SignalTabMoved:
+		tab.ID = event.TabID
+		tab.WindowID = int(raw.WindowID)
+		tab.Index = int(raw.ToIndex)
+		event.FromIndex = int(raw.FromIndex)
+		c.tabs[key] = tab
+	case dbus.SignalTabActivated:
+		windowID := int(raw.WindowID)
+		if known {
+			windowID = tab.WindowID
+			tab.Active = true
+			c.tabs[key] = tab
+		}
+		c.deactivate(raw.Browser, windowID, key)
+	case dbus.SignalTabRemoved:
+		delete(c.tabs, key)
+		if c.seeding[raw.Browser] {
+			c.removed[key] = true
+		}
+	}
+
+	if tab.WindowID != 0 {
+		event.WindowID = tab.WindowID
+	}
+	event.Index = tab.Index
+	event.Title = tab.Title
+	event.URL = tab.URL
+
+	return event, true
+}
+
+// deactivate clears Active on the tabs of a browser window but the one at
+// key, which the browser just activated
+func (c *tabCache) deactivate(browser string, windowID int, key string) {
+	for other, tab := range c.tabs {
+		if other != key && tab.Active && tab.WindowID == windowID && strings.HasPrefix(other, browser+"/") {
+			tab.Active = false
+			c.tabs[other] = tab
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package client
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// testCache returns a cache of Firefox and Chrome, seeded with two windows of
+// Firefox tabs and one of Chrome
+func testCache() *tabCache {
+	c := newTabCache()
+	c.connect("Firefox")
+	c.connect("Chrome")
+	c.seed("Firefox", []dbus.TabInfo{
+		{ID: "f.1.10", Title: "one", URL: "https://one.example", Index: 0, Active: true},
+		{ID: "f.1.11", Title: "two", URL: "https://two.example", Index: 1},
+		{ID: "f.2.20", Title: "three", URL: "https://three.example", Index: 0, Active: true},
+	})
+	c.seed("Chrome", []dbus.TabInfo{
+		{ID: "c.1.10", Title: "other", URL: "https://other.example", Index: 0, Active: true},
+	})
+	return c
+}
+
+// activeTabs returns the IDs of the active tabs in the cache, sorted
+func activeTabs(c *tabCache) string {
+	var ids []string
+	for _, tab := range c.tabs {
+		if tab.Active {
+			ids = append(ids, tab.ID)
+		}
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, " ")
+}
+
+func TestTabCacheApply(t *testing.T) {
+	c := testCache()
+
+	tests := []struct {
+		name   string
+		raw    dbus.Event
+		want   types.TabEvent
+		active string // active tabs after the event
+	}{
+		{
+			name:   "activated completes the tab and deactivates the others in its window",
+			raw:    dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabActivated, TabID: "f.1.11", WindowID: 1},
+			want:   types.TabEvent{Event: types.EventActivated, TabID: "f.1.11", WindowID: 1, Index: 1, Title: "two", URL: "https://two.example"},
+			active: "c.1.10 f.1.11 f.2.20",
+		},
+		{
+			name:   "activated again",
+			raw:    dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabActivated, TabID: "f.1.10", WindowID: 1},
+			want:   types.TabEvent{Event: types.EventActivated, TabID: "f.1.10", WindowID: 1, Index: 0, Title: "one", URL: "https://one.example"},
+			active: "c.1.10 f.1.10 f.2.20",
+		},
+		{
+			name:   "updated stores the new state",
+			raw:    dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabUpdated, TabID: "f.1.11", WindowID: 1, Tab: &dbus.TabInfo{ID: "f.1.11", Title: "two!", URL: "https://two.example/2", Index: 1}},
+			want:   types.TabEvent{Event: types.EventUpdated, TabID: "f.1.11", WindowID: 1, Index: 1, Title: "two!", URL: "https://two.example/2"},
+			active: "c.1.10 f.1.10 f.2.20",
+		},
+		{
+			name:   "moved to another window keeps title and URL",
+			raw:    dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabMoved, TabID: "f.2.11", WindowID: 2, FromIndex: 1, ToIndex: 1},
+			want:   types.TabEvent{Event: types.EventMoved, TabID: "f.2.11", WindowID: 2, Index: 1, FromIndex: 1, Title: "two!", URL: "https://two.example/2"},
+			active: "c.1.10 f.1.10 f.2.20",
+		},
+		{
+			name:   "activated in its new window",
+			raw:    dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabActivated, TabID: "f.2.11", WindowID: 2},
+			want:   types.TabEvent{Event: types.EventActivated, TabID: "f.2.11", WindowID: 2, Index: 1, Title: "two!", URL: "https://two.example/2"},
+			active: "c.1.10 f.1.10 f.2.11",
+		},
+		{
+			name:   "removed reports the last known state",
+			raw:    dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabRemoved, TabID: "f.1.10", WindowID: 1},
+			want:   types.TabEvent{Event: types.EventRemoved, TabID: "f.1.10", WindowID: 1, Index: 0, Title: "one", URL: "https://one.example"},
+			active: "c.1.10 f.2.11",
+		},
+		{
+			name:   "unknown tab",
+			raw:    dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabRemoved, TabID: "f.3.99", WindowID: 3},
+			want:   types.TabEvent{Event: types.EventRemoved, TabID: "f.3.99", WindowID: 3},
+			active: "c.1.10 f.2.11",
+		},
+		{
+			name:   "disconnected forgets the browser's tabs",
+			raw:    dbus.Event{Browser: "Chrome", Signal: dbus.SignalBrowserDisconnected},
+			want:   types.TabEvent{Event: types.EventDisconnected},
+			active: "f.2.11",
+		},
+	}
+
+	// The cases run in order on the same cache
+	for _, tt := range tests {
+		got, ok := c.apply(tt.raw)
+		if !ok {
+			t.Fatalf("%s: event not reported", tt.name)
+		}
+		tt.want.Browser = tt.raw.Browser
+		got.Time = tt.want.Time
+		if got != tt.want {
+			t.Errorf("%s: event = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if active := activeTabs(c); active != tt.active {
+			t.Errorf("%s: active tabs = %s, want %s", tt.name, active, tt.active)
+		}
+	}
+}
+
+func TestTabCachePrefixes(t *testing.T) {
+	c := newTabCache()
+	c.connect("Firefox")
+	c.connect("Firefox:work")
+
+	event, _ := c.apply(dbus.Event{Browser: "Firefox:work", Signal: dbus.SignalTabRemoved, TabID: "f.1.10", WindowID: 1})
+	if want := c.prefixes["Firefox:work"] + "1.10"; event.TabID != want {
+		t.Errorf("tab ID = %s, want %s", event.TabID, want)
+	}
+}
+
+func TestTabCacheSeed(t *testing.T) {
+	c := newTabCache()
+	c.connect("Firefox")
+	c.seeding["Firefox"] = true
+
+	// Events arrive while the tab list is being read, and the list read
+	// before them
+	c.apply(dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabUpdated, TabID: "f.1.11", WindowID: 1, Tab: &dbus.TabInfo{ID: "f.1.11", Title: "new title", Index: 1}})
+	c.apply(dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabRemoved, TabID: "f.1.12", WindowID: 1})
+	c.seed("Firefox", []dbus.TabInfo{
+		{ID: "f.1.10", Title: "kept", Index: 0},
+		{ID: "f.1.11", Title: "old title", Index: 1},
+		{ID: "f.1.12", Title: "removed", Index: 2},
+	})
+
+	var got []string
+	for _, tab := range c.tabs {
+		got = append(got, tab.ID+" "+tab.Title)
+	}
+	sort.Strings(got)
+	want := "f.1.10 kept, f.1.11 new title"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("cache = %v, want %s", got, want)
+	}
+	if c.seeding["Firefox"] || len(c.removed) != 0 {
+		t.Errorf("seed left seeding = %v, removed = %v", c.seeding, c.removed)
+	}
+
+	// Once seeded, removals are not remembered
+	c.apply(dbus.Event{Browser: "Firefox", Signal: dbus.SignalTabRemoved, TabID: "f.1.10", WindowID: 1})
+	if len(c.removed) != 0 {
+		t.Errorf("removed = %v after the seed, want none", c.removed)
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/tabctl/tabctl/pkg/api"
+)
+
+// Reply is the answer of one browser to a call made to several at once
+type Reply[T any] struct {
+	Client api.Client
+	Value  T
+	Err    error
+}
+
+// FanOut calls every client at once and returns their replies in client
+// order once all have answered or failed, so a slow browser delays the
+// result by its own deadline only. Each call is bounded by its client's
+// timeout and context.
+func FanOut[T any](clients []api.Client, call func(api.Client) (T, error)) []Reply[T] {
+	replies := make([]Reply[T], len(clients))
+
+	var wg sync.WaitGroup
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := call(c)
+			replies[i] = Reply[T]{Client: c, Value: value, Err: err}
+		}()
+	}
+	wg.Wait()
+
+	return replies
+}
+
+// BrowserError is the failure of one browser in a call made to several
+type BrowserError struct {
+	Browser string
+	Err     error
+}
+
+func (e *BrowserError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Browser, e.Err)
+}
+
+func (e *BrowserError) Unwrap() error {
+	return e.Err
+}
+
+// failures returns the browsers whose reply is an error, in client order
+func failures[T any](replies []Reply[T]) []*BrowserError {
+	var failed []*BrowserError
+	for _, reply := range replies {
+		if reply.Err != nil {
+			failed = append(failed, &BrowserError{Browser: reply.Client.GetBrowser(), Err: reply.Err})
+		}
+	}
+	return failed
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	tabctlerrors "github.com/tabctl/tabctl/internal/errors"
+	"github.com/tabctl/tabctl/pkg/api"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// fakeClient is a browser with open tabs; the methods the tests do not call
+// are left to the nil api.Client
+type fakeClient struct {
+	api.Client
+	browser string
+	prefix  string
+	open    []string
+	delay   time.Duration
+	err     error // returned by every call
+}
+
+func (c *fakeClient) GetBrowser() string { return c.browser }
+func (c *fakeClient) GetPrefix() string  { return c.prefix }
+
+func (c *fakeClient) ListTabs() ([]types.Tab, error) {
+	time.Sleep(c.delay)
+	if c.err != nil {
+		return nil, c.err
+	}
+	tabs := make([]types.Tab, len(c.open))
+	for i, id := range c.open {
+		tabs[i] = types.Tab{ID: id}
+	}
+	return tabs, nil
+}
+
+// CloseTabs fails like the browser does if any of tabIDs is not open
+func (c *fakeClient) CloseTabs(tabIDs []string) error {
+	if c.err != nil {
+		return c.err
+	}
+	for _, tabID := range tabIDs {
+		if !slices.Contains(c.open, tabID) {
+			return fmt.Errorf("no tab with id %s", tabID)
+		}
+	}
+	return nil
+}
+
+func TestFanOut(t *testing.T) {
+	clients := []api.Client{
+		&fakeClient{browser: "Firefox", prefix: "f.", open: []string{"f.1.1"}, delay: 30 * time.Millisecond},
+		&fakeClient{browser: "Chrome", prefix: "c.", err: errors.New("mediator gone")},
+		&fakeClient{browser: "Brave", prefix: "b.", open: []string{"b.1.1", "b.1.2"}},
+	}
+
+	replies := FanOut(clients, api.Client.ListTabs)
+
+	// Replies come in client order, not in the order the browsers answered
+	for i, reply := range replies {
+		if reply.Client != clients[i] {
+			t.Errorf("reply %d is from %s, want %s", i, reply.Client.GetBrowser(), clients[i].GetBrowser())
+		}
+	}
+	if len(replies[0].Value) != 1 || len(replies[2].Value) != 2 {
+		t.Errorf("replies = %+v, want the tabs of Firefox and Brave", replies)
+	}
+
+	failed := failures(replies)
+	if len(failed) != 1 || failed[0].Browser != "Chrome" {
+		t.Fatalf("failures = %v, want Chrome only", failed)
+	}
+	if got, want := failed[0].Error(), "Chrome: mediator gone"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
+
+func TestForEachTab(t *testing.T) {
+	bm := &BrowserManager{clients: []api.Client{
+		&fakeClient{browser: "Firefox", prefix: "f.", open: []string{"f.1.10", "f.1.11"}},
+		&fakeClient{browser: "Chrome", prefix: "c.", err: tabctlerrors.NewTimeoutError("CloseTabs", "1s")},
+		&fakeClient{browser: "Brave", prefix: "b.", open: []string{"b.1.10"}},
+	}}
+
+	tabIDs := []string{"f.1.10", "f.1.99", "c.1.10", "b.1.10", "z.1.10", "f.1", "f.1.11"}
+	results, err := bm.forEachTab(tabIDs, api.Client.CloseTabs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, result := range results {
+		got = append(got, result.TabID+" "+result.Status)
+	}
+	want := []string{
+		"f.1.10 " + types.TabStatusOK,
+		"f.1.99 " + types.TabStatusNotFound,
+		"c.1.10 " + types.TabStatusUnavailable,
+		"b.1.10 " + types.TabStatusOK,
+		"z.1.10 " + types.TabStatusUnavailable,
+		"f.1 " + types.TabStatusError,
+		"f.1.11 " + types.TabStatusOK,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+
+	if failed := bm.Failures(); len(failed) != 1 || failed[0].Browser != "Chrome" {
+		t.Errorf("failures = %v, want Chrome only", failed)
+	}
+}
+
+func TestTabOutcomes(t *testing.T) {
+	tests := []struct {
+		name   string
+		client *fakeClient
+		tabIDs []string
+		want   []types.TabResult
+		err    bool
+	}{
+		{
+			name:   "all found",
+			client: &fakeClient{open: []string{"f.1.1", "f.1.2"}},
+			tabIDs: []string{"f.1.1", "f.1.2"},
+			want: []types.TabResult{
+				{TabID: "f.1.1", Status: types.TabStatusOK},
+				{TabID: "f.1.2", Status: types.TabStatusOK},
+			},
+		},
+		{
+			name:   "unknown tabs are not found and the others retried",
+			client: &fakeClient{open: []string{"f.1.1"}},
+			tabIDs: []string{"f.1.9", "f.1.1", "f.2.8"},
+			want: []types.TabResult{
+				{TabID: "f.1.9", Status: types.TabStatusNotFound, Error: "tab not found"},
+				{TabID: "f.2.8", Status: types.TabStatusNotFound, Error: "tab not found"},
+				{TabID: "f.1.1", Status: types.TabStatusOK},
+			},
+		},
+		{
+			name:   "none found",
+			client: &fakeClient{},
+			tabIDs: []string{"f.1.9"},
+			want: []types.TabResult{
+				{TabID: "f.1.9", Status: types.TabStatusNotFound, Error: "tab not found"},
+			},
+		},
+		{
+			name:   "browser does not answer",
+			client: &fakeClient{err: tabctlerrors.NewTimeoutError("CloseTabs", "1s")},
+			tabIDs: []string{"f.1.1"},
+			want: []types.TabResult{
+				{TabID: "f.1.1", Status: types.TabStatusUnavailable, Error: "timeout error: operation 'CloseTabs' timed out after 1s"},
+			},
+			err: true,
+		},
+		{
+			name:   "browser fails and cannot list its tabs",
+			client: &fakeClient{err: errors.New("broken")},
+			tabIDs: []string{"f.1.1"},
+			want: []types.TabResult{
+				{TabID: "f.1.1", Status: types.TabStatusError, Error: "broken"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tabOutcomes(tt.client, tt.tabIDs, api.Client.CloseTabs)
+			if (err != nil) != tt.err {
+				t.Errorf("err = %v, want error %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outcomes = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
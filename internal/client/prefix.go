@@ -0,0 +1,151 @@
+package client
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tabctl/tabctl/internal/config"
+	"github.com/tabctl/tabctl/internal/dbus"
+)
+
+// defaultPrefixes are the built-in tab ID prefixes of known browsers,
+// keyed by lowercase browser name
+var defaultPrefixes = map[string]string{
+	"firefox":   "f.",
+	"chrome":    "c.",
+	"chromium":  "ch.",
+	"brave":     "b.",
+	"zen":       "z.",
+	"librewolf": "lw.",
+	"waterfox":  "w.",
+	"vivaldi":   "v.",
+	"edge":      "e.",
+	"opera":     "o.",
+}
+
+// AssignPrefixes gives every browser a unique tab ID prefix. Known browsers
+// always get the same prefix, which can be overridden in the prefixes
+// section of the config file or $TABCTL_PREFIXES ("Brave=b,Zen=z"). Other
+// browsers get the shortest abbreviation of their name that no known or
+// configured browser uses, so it depends on the name alone. If two browsers
+// still end up with the same prefix, the later one by name gets a number
+// appended ("b2."); that number depends on which browsers are connected.
+func AssignPrefixes(browsers []string) map[string]string {
+	return assignPrefixes(browsers, configuredPrefixes())
+}
+
+// assignPrefixes assigns prefixes with configured as the prefixes of known
+// browsers, keyed by lowercase name
+func assignPrefixes(browsers []string, configured map[string]string) map[string]string {
+	reserved := make(map[string]bool)
+	for _, prefix := range configured {
+		reserved[prefix] = true
+	}
+
+	sorted := append([]string(nil), browsers...)
+	sort.Strings(sorted)
+
+	used := make(map[string]bool)
+	assigned := make(map[string]string, len(sorted))
+	for _, browser := range sorted {
+		if _, ok := assigned[browser]; ok {
+			continue
+		}
+
+		// Profiles ("Brave:work") and further instances ("Brave#2") share the
+		// prefix of their browser unless configured on their own; the number
+		// suffix keeps them apart
+		name := dbus.ParseBrowserName(browser).Browser
+		prefix, ok := configured[strings.ToLower(browser)]
+		if !ok {
+			prefix, ok = configured[strings.ToLower(name)]
+		}
+		if !ok {
+			prefix = abbreviate(name, reserved)
+		}
+
+		base := strings.TrimSuffix(prefix, ".")
+		for n := 2; used[prefix]; n++ {
+			prefix = base + strconv.Itoa(n) + "."
+		}
+
+		used[prefix] = true
+		assigned[browser] = prefix
+	}
+
+	return assigned
+}
+
+// configuredPrefixes returns the built-in prefixes merged with the
+// overrides from the settings. A broken config file leaves the overrides
+// from the environment.
+func configuredPrefixes() map[string]string {
+	settings, _ := config.Load()
+	return withOverrides(settings.Section(config.SectionPrefixes))
+}
+
+// withOverrides returns the built-in prefixes merged with overrides, keyed by
+// browser name. Invalid prefixes are ignored.
+func withOverrides(overrides map[string]string) map[string]string {
+	prefixes := make(map[string]string, len(defaultPrefixes))
+	for browser, prefix := range defaultPrefixes {
+		prefixes[browser] = prefix
+	}
+
+	for browser, prefix := range overrides {
+		prefix = strings.TrimSuffix(strings.TrimSpace(prefix), ".")
+		if !validPrefix(prefix) {
+			continue
+		}
+		prefixes[strings.ToLower(strings.TrimSpace(browser))] = prefix + "."
+	}
+
+	return prefixes
+}
+
+// abbreviate returns the shortest leading part of the browser name that is
+// not reserved, falling back to "u." for names without letters
+func abbreviate(browser string, reserved map[string]bool) string {
+	var letters []rune
+	for _, r := range strings.ToLower(browser) {
+		if unicode.IsLetter(r) && r < unicode.MaxASCII {
+			letters = append(letters, r)
+		}
+	}
+	if len(letters) == 0 {
+		return "u."
+	}
+
+	for n := 1; n <= len(letters); n++ {
+		prefix := string(letters[:n]) + "."
+		if !reserved[prefix] {
+			return prefix
+		}
+	}
+	return string(letters) + "."
+}
+
+// validPrefix reports whether prefix (without the dot) can start a tab ID
+func validPrefix(prefix string) bool {
+	if prefix == "" {
+		return false
+	}
+	for _, r := range prefix {
+		if r >= unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// rewritePrefix replaces the prefix of a tab or window ID ("c.1.2") with
+// prefix. The extensions only know their own fixed prefix, so IDs coming
+// from a mediator are rewritten to the prefix assigned to its browser.
+func rewritePrefix(id, prefix string) string {
+	if i := strings.Index(id, "."); i >= 0 {
+		return prefix + id[i+1:]
+	}
+	return id
+}
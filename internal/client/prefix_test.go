@@ -0,0 +1,134 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tabctl/tabctl/internal/config"
+)
+
+func TestAssignPrefixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		browsers []string
+		want     map[string]string
+	}{
+		{
+			name:     "known browsers",
+			browsers: []string{"Firefox", "Chrome", "Chromium", "Brave"},
+			want:     map[string]string{"Firefox": "f.", "Chrome": "c.", "Chromium": "ch.", "Brave": "b."},
+		},
+		{
+			name:     "known names ignore case",
+			browsers: []string{"firefox", "ZEN"},
+			want:     map[string]string{"firefox": "f.", "ZEN": "z."},
+		},
+		{
+			name:     "profiles and instances share the browser prefix",
+			browsers: []string{"Firefox#2", "Firefox:work", "Firefox"},
+			want:     map[string]string{"Firefox": "f.", "Firefox#2": "f2.", "Firefox:work": "f3."},
+		},
+		{
+			name:     "unknown browsers skip known prefixes",
+			browsers: []string{"Floorp", "Basilisk", "Thorium"},
+			want:     map[string]string{"Floorp": "fl.", "Basilisk": "ba.", "Thorium": "t."},
+		},
+		{
+			name:     "unknown browser profiles",
+			browsers: []string{"Floorp:work", "Floorp"},
+			want:     map[string]string{"Floorp": "fl.", "Floorp:work": "fl2."},
+		},
+		{
+			name:     "unknown browsers abbreviated alike",
+			browsers: []string{"Flock", "Floorp"},
+			want:     map[string]string{"Flock": "fl.", "Floorp": "fl2."},
+		},
+		{
+			name:     "names without letters",
+			browsers: []string{"1234"},
+			want:     map[string]string{"1234": "u."},
+		},
+		{
+			name:     "duplicate names",
+			browsers: []string{"Zen", "Zen"},
+			want:     map[string]string{"Zen": "z."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := assignPrefixes(tt.browsers, withOverrides(nil))
+			assertPrefixes(t, got, tt.want)
+		})
+	}
+}
+
+// An unknown browser keeps its prefix whichever browsers are connected, short
+// of one abbreviated alike
+func TestAssignPrefixesStable(t *testing.T) {
+	sets := [][]string{
+		{"Floorp"},
+		{"Floorp", "Firefox"},
+		{"Floorp", "Firefox", "Falkon:work", "Chromium"},
+		{"Floorp", "Falkon"},
+	}
+	for _, browsers := range sets {
+		if got := assignPrefixes(browsers, withOverrides(nil))["Floorp"]; got != "fl." {
+			t.Errorf("Floorp among %v gets %s, want fl.", browsers, got)
+		}
+	}
+}
+
+func TestAssignPrefixesOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.FileName)
+	content := "prefixes:\n  Brave: br.\n  Firefox:work: fw\n  Zen: \"z!\"\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TABCTL_PREFIXES", "Chrome=g,flock=fk")
+
+	settings, err := config.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configured := withOverrides(settings.Section(config.SectionPrefixes))
+
+	browsers := []string{"Brave", "Chrome", "Firefox", "Firefox:work", "Firefox:home", "Zen", "Flock", "Galeon"}
+	assertPrefixes(t, assignPrefixes(browsers, configured), map[string]string{
+		"Brave":        "br.",
+		"Chrome":       "g.",
+		"Firefox":      "f.",
+		"Firefox:work": "fw.",
+		"Firefox:home": "f2.",
+		"Zen":          "z.", // invalid override ignored
+		"Flock":        "fk.",
+		"Galeon":       "ga.", // g. is taken by Chrome
+	})
+}
+
+func assertPrefixes(t *testing.T, got, want map[string]string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("prefixes = %v, want %v", got, want)
+	}
+	for browser, prefix := range want {
+		if got[browser] != prefix {
+			t.Errorf("prefix of %s = %q, want %q", browser, got[browser], prefix)
+		}
+	}
+}
+
+func TestRewritePrefix(t *testing.T) {
+	tests := []struct{ id, prefix, want string }{
+		{"f.1.2", "f2.", "f2.1.2"},
+		{"c.1874583011.1874583012", "g.", "g.1874583011.1874583012"},
+		{"f.1", "fw.", "fw.1"},
+		{"12", "f.", "12"},
+	}
+	for _, tt := range tests {
+		if got := rewritePrefix(tt.id, tt.prefix); got != tt.want {
+			t.Errorf("rewritePrefix(%q, %q) = %q, want %q", tt.id, tt.prefix, got, tt.want)
+		}
+	}
+}
@@ -7,8 +7,34 @@ import (
 // Default timeout values for native messaging
 const (
 	TransportTimeout = 30 * time.Second
+	// ScreenshotTimeout covers capturing and encoding the visible tab
+	ScreenshotTimeout = 60 * time.Second
+	// ContentTimeout covers running extraction scripts in every tab
+	ContentTimeout = 60 * time.Second
+	// BrowserQueryTimeout bounds asking the extension for the browser name at
+	// startup, which must not hold up registering on D-Bus for long
+	BrowserQueryTimeout = 3 * time.Second
+	// ScreenshotSettleDelay lets a freshly activated tab paint before capture
+	ScreenshotSettleDelay = 500 * time.Millisecond
+	// DBusCallTimeout bounds a CLI call into a mediator. It exceeds the
+	// longest mediator budget so the mediator reports its own timeout first.
+	DBusCallTimeout = 65 * time.Second
 )
 
+// Session history defaults for tabctl daemon
+const (
+	// SnapshotInterval is how often the daemon snapshots the open tabs
+	SnapshotInterval = 10 * time.Minute
+	// HistoryKeep caps the number of snapshots kept (two days at the default interval)
+	HistoryKeep = 288
+	// HistoryMaxAge drops snapshots older than a week
+	HistoryMaxAge = 7 * 24 * time.Hour
+)
+
+// MediatorLogFile is the default mediator log; "{pid}" is replaced with the
+// mediator's PID to keep browser sessions apart
+const MediatorLogFile = "/tmp/tabctl-mediator-{pid}.log"
+
 // Native messaging host names
 const (
 	NativeHostName = "tabctl_mediator"
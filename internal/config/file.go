@@ -0,0 +1,490 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tabctl/tabctl/internal/platform"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file in the config directory
+const FileName = "config"
+
+// EnvPrefix starts the environment variable of every setting: "format" is
+// read from TABCTL_FORMAT and "mediator.log_file" from
+// TABCTL_MEDIATOR_LOG_FILE
+const EnvPrefix = "TABCTL_"
+
+// Sources of a setting's value, from lowest to highest precedence. Command
+// line flags override all of them.
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// Kinds of setting values
+const (
+	KindString   = "string"
+	KindBool     = "bool"
+	KindDuration = "duration"
+)
+
+// Key describes a single-valued setting
+type Key struct {
+	Name    string
+	Kind    string
+	Default string
+	Help    string
+}
+
+// Keys are the single-valued settings, in the order config show lists them
+var Keys = []Key{
+	{Name: "format", Kind: KindString, Default: "tsv", Help: "default output format (--format)"},
+	{Name: "delimiter", Kind: KindString, Default: "\t", Help: "default field delimiter (--delimiter)"},
+	{Name: "no_headers", Kind: KindBool, Default: "false", Help: "suppress headers by default (--no-headers)"},
+	{Name: "browser", Kind: KindString, Help: "default target browser (--browser)"},
+	{Name: "timeout", Kind: KindDuration, Default: DBusCallTimeout.String(), Help: "default call timeout (--timeout)"},
+	{Name: "columns", Kind: KindString, Help: "default TSV columns of tab lists (--columns)"},
+	{Name: "template", Kind: KindString, Help: "default tab template of --format template (--template)"},
+	{Name: KeyDefaultBrowser, Kind: KindString, Help: "browser for commands that act on a single browser"},
+	{Name: KeyRofiRow, Kind: KindString, Help: "row template of tabctl rofi"},
+	{Name: KeyMediatorLogFile, Kind: KindString, Default: MediatorLogFile, Help: `mediator log file; "{pid}" is replaced, "off" disables logging`},
+}
+
+// Names of the settings read outside the CLI flags
+const (
+	KeyDefaultBrowser  = "default_browser"
+	KeyRofiRow         = "rofi_row"
+	KeyMediatorLogFile = "mediator.log_file"
+)
+
+// Sections
+const (
+	SectionPrefixes = "prefixes"
+	SectionAliases  = "aliases"
+	SectionFilters  = "filters"
+)
+
+// Sections are the settings that map names to values. In the environment
+// they are written as "name=value" pairs separated by commas; a comma inside
+// a double-quoted string, as in a filter, does not separate pairs.
+var Sections = []Key{
+	{Name: SectionPrefixes, Help: `tab ID prefix per browser, e.g. Brave: "b"`},
+	{Name: SectionAliases, Help: `--browser aliases, e.g. work: "Firefox:work"`},
+	{Name: SectionFilters, Help: `named filters, used as --filter @name`},
+}
+
+// Entry is the effective value of one setting
+type Entry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// Settings are the merged values of the config file and the environment
+type Settings struct {
+	values   map[string]Entry            // single-valued settings by key
+	sections map[string]map[string]Entry // section -> name -> entry
+}
+
+var (
+	loadOnce     sync.Once
+	loadSettings *Settings
+	loadErr      error
+)
+
+// Load returns the settings from the config file and the environment. The
+// file is read once per process. On error the settings hold the defaults
+// and the environment only.
+func Load() (*Settings, error) {
+	loadOnce.Do(func() {
+		path, err := Path()
+		if err != nil {
+			loadSettings, loadErr = newSettings(nil, os.Environ()), err
+			return
+		}
+		loadSettings, loadErr = LoadFile(path)
+	})
+	return loadSettings, loadErr
+}
+
+// LoadFile returns the settings from the config file at path, which may be
+// missing, and the environment
+func LoadFile(path string) (*Settings, error) {
+	file, err := readFile(path)
+	if err != nil {
+		return newSettings(nil, os.Environ()), err
+	}
+	return newSettings(file, os.Environ()), nil
+}
+
+// Path returns the path of the config file
+func Path() (string, error) {
+	configDir, err := platform.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, FileName), nil
+}
+
+// readFile parses the config file into flat "key" and "section.name"
+// values. A missing file has no values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten("", raw, values); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	for key, value := range values {
+		if err := Validate(key, value); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	return values, nil
+}
+
+// flatten turns nested mappings into dotted keys
+func flatten(prefix string, raw map[string]interface{}, values map[string]string) error {
+	for name, value := range raw {
+		key := prefix + name
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(key+".", v, values); err != nil {
+				return err
+			}
+		case nil:
+			// An empty entry, as left by "key:" alone
+		case []interface{}:
+			return fmt.Errorf("%s: lists are not supported", key)
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// newSettings merges the file values, then the environment, over the
+// defaults
+func newSettings(file map[string]string, environ []string) *Settings {
+	s := &Settings{
+		values:   make(map[string]Entry),
+		sections: make(map[string]map[string]Entry),
+	}
+	for _, key := range Keys {
+		s.values[key.Name] = Entry{Key: key.Name, Value: key.Default, Source: SourceDefault}
+	}
+	for _, section := range Sections {
+		s.sections[section.Name] = make(map[string]Entry)
+	}
+
+	for key, value := range file {
+		s.set(key, value, SourceFile)
+	}
+
+	env := make(map[string]string)
+	for _, entry := range environ {
+		if name, value, ok := strings.Cut(entry, "="); ok && strings.HasPrefix(name, EnvPrefix) {
+			env[name] = value
+		}
+	}
+	for _, key := range Keys {
+		if value, ok := env[EnvName(key.Name)]; ok && Validate(key.Name, value) == nil {
+			s.set(key.Name, value, SourceEnv)
+		}
+	}
+	for _, section := range Sections {
+		for _, pair := range splitPairs(env[EnvName(section.Name)]) {
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok || strings.TrimSpace(name) == "" {
+				continue
+			}
+			s.set(section.Name+"."+strings.TrimSpace(name), strings.TrimSpace(value), SourceEnv)
+		}
+	}
+
+	return s
+}
+
+// splitPairs splits the value of a section variable at the commas outside
+// double-quoted strings, in which \" escapes a quote as in filters
+func splitPairs(value string) []string {
+	var pairs []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			if quoted && i+1 < len(value) && value[i+1] == '"' {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				pairs = append(pairs, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(pairs, value[start:])
+}
+
+// set stores a value under a key or "section.name"
+func (s *Settings) set(key, value, source string) {
+	if _, ok := s.values[key]; ok {
+		s.values[key] = Entry{Key: key, Value: value, Source: source}
+		return
+	}
+	section, name, _ := strings.Cut(key, ".")
+	if entries, ok := s.sections[section]; ok {
+		entries[name] = Entry{Key: key, Value: value, Source: source}
+	}
+}
+
+// EnvName returns the environment variable of a key or section
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Get returns the effective value of a key or "section.name". Section
+// names are matched ignoring case.
+func (s *Settings) Get(key string) (Entry, bool) {
+	if entry, ok := s.values[key]; ok {
+		return entry, true
+	}
+	section, name, _ := strings.Cut(key, ".")
+	for entryName, entry := range s.sections[section] {
+		if strings.EqualFold(entryName, name) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// String returns the value of a single-valued setting
+func (s *Settings) String(key string) string {
+	return s.values[key].Value
+}
+
+// Section returns the names and values of a section
+func (s *Settings) Section(section string) map[string]string {
+	values := make(map[string]string, len(s.sections[section]))
+	for name, entry := range s.sections[section] {
+		values[name] = entry.Value
+	}
+	return values
+}
+
+// Entries returns every setting in display order: single-valued settings,
+// then the sections by name
+func (s *Settings) Entries() []Entry {
+	entries := make([]Entry, 0, len(s.values))
+	for _, key := range Keys {
+		entries = append(entries, s.values[key.Name])
+	}
+	for _, section := range Sections {
+		names := make([]string, 0, len(s.sections[section.Name]))
+		for name := range s.sections[section.Name] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			entries = append(entries, s.sections[section.Name][name])
+		}
+	}
+	return entries
+}
+
+// ValidateKey checks that key is a known setting or "section.name"
+func ValidateKey(key string) error {
+	if _, ok := findKey(key); ok {
+		return nil
+	}
+	section, name, _ := strings.Cut(key, ".")
+	if isSection(section) && name != "" {
+		return nil
+	}
+	return fmt.Errorf("unknown setting %q (see \"tabctl config show\")", key)
+}
+
+// Validate checks that key is a known setting and that value suits it
+func Validate(key, value string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	k, _ := findKey(key)
+	switch k.Kind {
+	case KindBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", key, value)
+		}
+	case KindDuration:
+		if _, err := time.ParseDuration(value); err != nil {
+			return fmt.Errorf("%s: invalid duration %q", key, value)
+		}
+	}
+	return nil
+}
+
+// Set writes a value into the config file at path, creating the file if
+// needed. An empty value removes the key. Comments and the order of other
+// keys are kept.
+func Set(path, key, value string) error {
+	validate := Validate
+	if value == "" {
+		validate = func(key, _ string) error { return ValidateKey(key) }
+	}
+	if err := validate(key, value); err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("invalid config file %s: not a mapping", path)
+	}
+
+	// Names in sections are kept whole even if they contain dots
+	elems := strings.Split(key, ".")
+	if isSection(elems[0]) {
+		elems = []string{elems[0], strings.TrimPrefix(key, elems[0]+".")}
+	}
+	if value == "" {
+		unsetNode(root, elems)
+	} else {
+		setNode(root, elems, scalarNode(key, value))
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode config file: %w", err)
+	}
+	encoder.Close()
+
+	if err := platform.EnsureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+func isSection(name string) bool {
+	for _, section := range Sections {
+		if section.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func findKey(name string) (Key, bool) {
+	for _, key := range Keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// scalarNode returns value as a YAML scalar, typed as the key's kind so
+// booleans are written unquoted and strings such as "true" stay strings
+func scalarNode(key, value string) *yaml.Node {
+	tag := "!!str"
+	if k, _ := findKey(key); k.Kind == KindBool {
+		b, _ := strconv.ParseBool(value)
+		value, tag = strconv.FormatBool(b), "!!bool"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value}
+}
+
+// setNode sets the value at path in a mapping, creating mappings on the way
+func setNode(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i < len(mapping.Content)-1; i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			mapping.Content[i+1] = value
+			return
+		}
+		child := mapping.Content[i+1]
+		if child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			mapping.Content[i+1] = child
+		}
+		setNode(child, path[1:], value)
+		return
+	}
+
+	keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) == 1 {
+		mapping.Content = append(mapping.Content, keyNode, value)
+		return
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, keyNode, child)
+	setNode(child, path[1:], value)
+}
+
+// unsetNode removes the value at path, and mappings it leaves empty
+func unsetNode(mapping *yaml.Node, path []string) {
+	for i := 0; i < len(mapping.Content)-1; i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		child := mapping.Content[i+1]
+		if len(path) > 1 {
+			if child.Kind != yaml.MappingNode {
+				return
+			}
+			unsetNode(child, path[1:])
+			if len(child.Content) > 0 {
+				return
+			}
+		}
+		mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+		return
+	}
+}
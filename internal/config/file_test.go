@@ -0,0 +1,262 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes a config file into a temporary directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func assertEntry(t *testing.T, s *Settings, key, value, source string) {
+	t.Helper()
+	entry, ok := s.Get(key)
+	if !ok {
+		t.Fatalf("Get(%q): not found", key)
+	}
+	if entry.Value != value || entry.Source != source {
+		t.Errorf("Get(%q) = %q from %s, want %q from %s", key, entry.Value, entry.Source, value, source)
+	}
+}
+
+func TestLoadFilePrecedence(t *testing.T) {
+	path := writeConfig(t, `
+format: json
+timeout: 10s
+delimiter: ","
+mediator:
+  log_file: "off"
+`)
+	t.Setenv("TABCTL_FORMAT", "csv")
+	t.Setenv("TABCTL_TIMEOUT", "soon") // invalid: the file value stays
+	t.Setenv("TABCTL_NO_HEADERS", "true")
+
+	s, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEntry(t, s, "format", "csv", SourceEnv)
+	assertEntry(t, s, "timeout", "10s", SourceFile)
+	assertEntry(t, s, "delimiter", ",", SourceFile)
+	assertEntry(t, s, "no_headers", "true", SourceEnv)
+	assertEntry(t, s, KeyMediatorLogFile, "off", SourceFile)
+	assertEntry(t, s, "browser", "", SourceDefault)
+	assertEntry(t, s, KeyRofiRow, "", SourceDefault)
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	s, err := LoadFile(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEntry(t, s, "format", "tsv", SourceDefault)
+	assertEntry(t, s, "timeout", DBusCallTimeout.String(), SourceDefault)
+}
+
+func TestLoadFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"bad duration", "timeout: soon\n", `timeout: invalid duration "soon"`},
+		{"bad boolean", "no_headers: maybe\n", `no_headers: invalid boolean "maybe"`},
+		{"unknown key", "colour: red\n", `unknown setting "colour"`},
+		{"list", "prefixes:\n  - b\n", "prefixes: lists are not supported"},
+		{"not yaml", "format: [json\n", "failed to parse config file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TABCTL_FORMAT", "yaml")
+
+			s, err := LoadFile(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("LoadFile error = %v, want it to contain %q", err, tt.want)
+			}
+			// The defaults and the environment still apply
+			assertEntry(t, s, "format", "yaml", SourceEnv)
+			assertEntry(t, s, "timeout", DBusCallTimeout.String(), SourceDefault)
+		})
+	}
+}
+
+func TestSectionsFromEnv(t *testing.T) {
+	file := map[string]string{
+		"prefixes.Firefox": "ff",
+		"prefixes.Brave":   "br",
+		"aliases.work":     "Firefox:work",
+	}
+	environ := []string{
+		"TABCTL_PREFIXES= Brave=b, Zen=z,broken,=x",
+		"TABCTL_FILTERS=gh=domain:github.com",
+		"OTHER_PREFIXES=Chrome=c",
+	}
+
+	s := newSettings(file, environ)
+
+	want := map[string]string{"Firefox": "ff", "Brave": "b", "Zen": "z"}
+	got := s.Section(SectionPrefixes)
+	if len(got) != len(want) {
+		t.Errorf("Section(prefixes) = %v, want %v", got, want)
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("prefixes.%s = %q, want %q", name, got[name], value)
+		}
+	}
+
+	assertEntry(t, s, "prefixes.Firefox", "ff", SourceFile)
+	assertEntry(t, s, "prefixes.brave", "b", SourceEnv) // names ignore case
+	assertEntry(t, s, "aliases.work", "Firefox:work", SourceFile)
+	assertEntry(t, s, "filters.gh", "domain:github.com", SourceEnv)
+}
+
+func TestSplitPairs(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"Brave=b,Zen=z", []string{"Brave=b", "Zen=z"}},
+		{"", []string{""}},
+		{`ab=title~"a,b",gh=domain:github.com`, []string{`ab=title~"a,b"`, "gh=domain:github.com"}},
+		{`q=title:"say \"hi, you\"",x=pinned`, []string{`q=title:"say \"hi, you\""`, "x=pinned"}},
+		{`re=title~"\d,",y=active`, []string{`re=title~"\d,"`, "y=active"}},
+		{`open="a,b`, []string{`open="a,b`}},
+	}
+	for _, tt := range tests {
+		if got := splitPairs(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPairs(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFiltersFromEnvKeepCommas(t *testing.T) {
+	s := newSettings(nil, []string{`TABCTL_FILTERS=ab=title~"a,b" or url:x,gh=domain:github.com`})
+
+	assertEntry(t, s, "filters.ab", `title~"a,b" or url:x`, SourceEnv)
+	assertEntry(t, s, "filters.gh", "domain:github.com", SourceEnv)
+}
+
+func TestSetKeepsCommentsAndOrder(t *testing.T) {
+	path := writeConfig(t, `# tabctl settings
+
+format: json # for scripts
+prefixes:
+  # short ones
+  Brave: br
+timeout: 10s
+`)
+
+	steps := []struct{ key, value string }{
+		{"timeout", "5s"},
+		{"no_headers", "true"},
+		{"browser", "true"},
+		{"prefixes.Zen", "zn"},
+		{"aliases.my.work", "Firefox:work"},
+		{"format", ""},
+	}
+	for _, step := range steps {
+		if err := Set(path, step.key, step.value); err != nil {
+			t.Fatalf("Set(%q, %q): %v", step.key, step.value, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# tabctl settings
+
+prefixes:
+  # short ones
+  Brave: br
+  Zen: zn
+timeout: 5s
+no_headers: true
+browser: "true"
+aliases:
+  my.work: Firefox:work
+`
+	if string(data) != want {
+		t.Errorf("config file =\n%s\nwant\n%s", data, want)
+	}
+
+	s, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEntry(t, s, "aliases.my.work", "Firefox:work", SourceFile)
+	assertEntry(t, s, "browser", "true", SourceFile)
+}
+
+func TestSetRemovesEmptySections(t *testing.T) {
+	path := writeConfig(t, "prefixes:\n  Brave: br\nformat: json\n")
+
+	if err := Set(path, "prefixes.Brave", ""); err != nil {
+		t.Fatal(err)
+	}
+	// Removing what is not there is not an error
+	if err := Set(path, "aliases.none", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "format: json\n" {
+		t.Errorf("config file = %q, want %q", data, "format: json\n")
+	}
+}
+
+func TestSetCreatesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tabctl", FileName)
+
+	if err := Set(path, KeyMediatorLogFile, "/tmp/m.log"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "mediator:\n  log_file: /tmp/m.log\n"
+	if string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+}
+
+func TestSetRejectsInvalid(t *testing.T) {
+	path := writeConfig(t, "format: json\n")
+
+	tests := []struct{ key, value, want string }{
+		{"colour", "red", `unknown setting "colour"`},
+		{"colour", "", `unknown setting "colour"`},
+		{"prefixes", "b", `unknown setting "prefixes"`},
+		{"timeout", "soon", `invalid duration "soon"`},
+		{"no_headers", "maybe", `invalid boolean "maybe"`},
+	}
+	for _, tt := range tests {
+		err := Set(path, tt.key, tt.value)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Set(%q, %q) error = %v, want it to contain %q", tt.key, tt.value, err, tt.want)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "format: json\n" {
+		t.Errorf("config file changed to %q", data)
+	}
+}
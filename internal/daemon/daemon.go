@@ -0,0 +1,208 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/internal/session"
+)
+
+// Daemon serves the manager service, routing each call to the mediators
+// currently on the bus. Tab IDs use the same prefixes as the CLI.
+type Daemon struct {
+	timeout time.Duration
+
+	mu      sync.RWMutex
+	current *generation
+
+	history  *session.History
+	interval time.Duration
+}
+
+var _ dbus.ManagerHandler = (*Daemon)(nil)
+
+// New creates a daemon whose calls into each mediator are bounded by
+// timeout; zero means no deadline.
+func New(timeout time.Duration) *Daemon {
+	return &Daemon{timeout: timeout}
+}
+
+// SetSnapshots makes the daemon snapshot the open tabs into history every
+// interval and once more on shutdown. A zero interval or nil history
+// disables snapshots.
+func (d *Daemon) SetSnapshots(history *session.History, interval time.Duration) {
+	d.history = history
+	d.interval = interval
+}
+
+// Run owns the manager name and serves it until ctx is done
+func (d *Daemon) Run(ctx context.Context) error {
+	manager, err := dbus.NewManager(d)
+	if err != nil {
+		return err
+	}
+	defer manager.Stop()
+
+	// Subscribe before the first discovery so no mediator slips through
+	watcher, err := dbus.NewPrivateClient()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	events, err := watcher.WatchEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	d.refresh()
+	if err := manager.Start(); err != nil {
+		return err
+	}
+
+	var tick <-chan time.Time
+	if d.history != nil && d.interval > 0 {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+		d.snapshot()
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				if tick != nil {
+					d.snapshot()
+				}
+				return nil
+			}
+			switch event.Signal {
+			case dbus.SignalBrowserConnected, dbus.SignalBrowserDisconnected:
+				d.refresh()
+			}
+		case <-tick:
+			d.snapshot()
+		}
+	}
+}
+
+// snapshot records the open tabs in the history. Failures are logged; they
+// must not stop the daemon.
+func (d *Daemon) snapshot() {
+	bm, done := d.manager()
+	defer done()
+
+	clients := bm.GetClients()
+	if len(clients) == 0 {
+		return
+	}
+
+	sess, err := session.Capture("", clients)
+	if err != nil {
+		log.Printf("Snapshot failed: %v", err)
+		return
+	}
+	if _, err := d.history.Snapshot(sess); err != nil {
+		log.Printf("Snapshot failed: %v", err)
+	}
+}
+
+// generation is a browser manager and the calls in flight on it
+type generation struct {
+	bm    *client.BrowserManager
+	calls sync.WaitGroup
+}
+
+// refresh rediscovers the mediators. Prefixes depend on the set of
+// browsers, so every change rebuilds the whole manager. The old manager is
+// closed once the calls and snapshots in flight on it have finished.
+func (d *Daemon) refresh() {
+	next := &generation{bm: client.NewBrowserManager("", d.timeout)}
+
+	d.mu.Lock()
+	old := d.current
+	d.current = next
+	d.mu.Unlock()
+
+	if old != nil {
+		go func() {
+			old.calls.Wait()
+			old.bm.Close()
+		}()
+	}
+}
+
+// manager returns the current browser manager and a function to call when
+// done with it
+func (d *Daemon) manager() (*client.BrowserManager, func()) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	d.current.calls.Add(1)
+	return d.current.bm, d.current.calls.Done
+}
+
+// ListBrowsers returns the names of the connected mediators, sorted
+func (d *Daemon) ListBrowsers() ([]string, error) {
+	bm, done := d.manager()
+	defer done()
+
+	clients := bm.GetClients()
+
+	browsers := make([]string, 0, len(clients))
+	for _, c := range clients {
+		browsers = append(browsers, c.GetBrowser())
+	}
+	sort.Strings(browsers)
+
+	return browsers, nil
+}
+
+// ListAllTabs returns the tabs of every connected browser
+func (d *Daemon) ListAllTabs() ([]dbus.TabInfo, error) {
+	bm, done := d.manager()
+	defer done()
+
+	if len(bm.GetClients()) == 0 {
+		return []dbus.TabInfo{}, nil
+	}
+
+	tabs, err := bm.ListAllTabs()
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]dbus.TabInfo, len(tabs))
+	for i, tab := range tabs {
+		infos[i] = dbus.TabInfo{
+			ID:     tab.ID,
+			Title:  tab.Title,
+			URL:    tab.URL,
+			Index:  int32(tab.Index),
+			Active: tab.Active,
+			Pinned: tab.Pinned,
+		}
+	}
+	return infos, nil
+}
+
+// ActivateTab activates a tab in the browser its ID prefix belongs to
+func (d *Daemon) ActivateTab(tabID string) error {
+	bm, done := d.manager()
+	defer done()
+
+	result, err := bm.ActivateTab(tabID)
+	if err == nil {
+		err = client.ResultsError(result)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to activate tab %s: %w", tabID, err)
+	}
+	return nil
+}
@@ -1,33 +1,87 @@
 package dbus
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/godbus/dbus/v5"
+	"github.com/tabctl/tabctl/internal/config"
+	"github.com/tabctl/tabctl/internal/errors"
 )
 
 type Client struct {
-	conn *dbus.Conn
+	conn    *dbus.Conn
+	shared  bool // conn is the process-wide session bus connection
+	ctx     context.Context
+	timeout time.Duration
 }
 
+// NewClient uses the session bus connection shared by the whole process.
+// Closing the client leaves that connection open.
 func NewClient() (*Client, error) {
 	conn, err := dbus.SessionBus()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
 	}
 
-	return &Client{conn: conn}, nil
+	return &Client{conn: conn, shared: true, ctx: context.Background(), timeout: config.DBusCallTimeout}, nil
 }
 
+// NewPrivateClient connects on a bus connection of its own, which closing the
+// client closes
+func NewPrivateClient() (*Client, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+
+	return &Client{conn: conn, ctx: context.Background(), timeout: config.DBusCallTimeout}, nil
+}
+
+// SetTimeout bounds every subsequent method call. Zero means no deadline.
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// SetContext makes ctx the parent of every subsequent method call, so
+// cancelling it aborts the calls in flight
+func (c *Client) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// callBrowser invokes a method on a browser's mediator, honoring the client
+// context and timeout
+func (c *Client) callBrowser(browser, method string, args ...interface{}) *dbus.Call {
+	obj := c.conn.Object(ServiceName(browser), ObjectPath(browser))
+
+	ctx := c.ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	call := obj.CallWithContext(ctx, InterfaceBrowser+"."+method, 0, args...)
+	if ctx.Err() == context.DeadlineExceeded {
+		call.Err = errors.NewTimeoutError(method, c.timeout.String())
+	}
+	return call
+}
+
+// Close closes the client's connection unless it is the shared one
 func (c *Client) Close() error {
-	if c.conn != nil {
+	if c.conn != nil && !c.shared {
 		return c.conn.Close()
 	}
 	return nil
 }
 
-func (c *Client) DiscoverBrowsers() ([]string, error) {
+// DiscoverBrowsers returns the mediators currently on the bus, sorted by name
+func (c *Client) DiscoverBrowsers() ([]BrowserInstance, error) {
 	var names []string
 	obj := c.conn.Object("org.freedesktop.DBus", "/org/freedesktop/DBus")
 	err := obj.Call("org.freedesktop.DBus.ListNames", 0).Store(&names)
@@ -35,28 +89,24 @@ func (c *Client) DiscoverBrowsers() ([]string, error) {
 		return nil, fmt.Errorf("failed to list D-Bus names: %w", err)
 	}
 
-	var browsers []string
+	var browsers []BrowserInstance
 	prefix := ServiceNameBase + "."
 	for _, name := range names {
 		if strings.HasPrefix(name, prefix) {
-			browser := strings.TrimPrefix(name, prefix)
-			if browser != "" && browser != "Manager" {
-				browsers = append(browsers, browser)
+			elements := strings.TrimPrefix(name, prefix)
+			if elements != "" && elements != "Manager" {
+				browsers = append(browsers, ParseBrowserName(browserFromElements(elements, ".")))
 			}
 		}
 	}
 
+	sort.Slice(browsers, func(i, j int) bool { return browsers[i].Name < browsers[j].Name })
 	return browsers, nil
 }
 
 func (c *Client) ListTabs(browser string) ([]TabInfo, error) {
-	serviceName := ServiceName(browser)
-	objectPath := ObjectPath(browser)
-
-	obj := c.conn.Object(serviceName, objectPath)
-
 	var tabs []TabInfo
-	err := obj.Call(InterfaceBrowser+".ListTabs", 0).Store(&tabs)
+	err := c.callBrowser(browser, "ListTabs").Store(&tabs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tabs: %w", err)
 	}
@@ -65,13 +115,8 @@ func (c *Client) ListTabs(browser string) ([]TabInfo, error) {
 }
 
 func (c *Client) ActivateTab(browser, tabID string) error {
-	serviceName := ServiceName(browser)
-	objectPath := ObjectPath(browser)
-
-	obj := c.conn.Object(serviceName, objectPath)
-
 	var success bool
-	err := obj.Call(InterfaceBrowser+".ActivateTab", 0, tabID).Store(&success)
+	err := c.callBrowser(browser, "ActivateTab", tabID).Store(&success)
 	if err != nil {
 		return fmt.Errorf("failed to activate tab: %w", err)
 	}
@@ -83,13 +128,8 @@ func (c *Client) ActivateTab(browser, tabID string) error {
 }
 
 func (c *Client) CloseTab(browser, tabID string) error {
-	serviceName := ServiceName(browser)
-	objectPath := ObjectPath(browser)
-
-	obj := c.conn.Object(serviceName, objectPath)
-
 	var success bool
-	err := obj.Call(InterfaceBrowser+".CloseTab", 0, tabID).Store(&success)
+	err := c.callBrowser(browser, "CloseTab", tabID).Store(&success)
 	if err != nil {
 		return fmt.Errorf("failed to close tab: %w", err)
 	}
@@ -101,16 +141,132 @@ func (c *Client) CloseTab(browser, tabID string) error {
 }
 
 func (c *Client) OpenTab(browser, url string) (string, error) {
-	serviceName := ServiceName(browser)
-	objectPath := ObjectPath(browser)
+	var tabID string
+	err := c.callBrowser(browser, "OpenTab", url).Store(&tabID)
+	if err != nil {
+		return "", fmt.Errorf("failed to open tab: %w", err)
+	}
+
+	return tabID, nil
+}
+
+func (c *Client) QueryTabs(browser, queryJSON string) ([]TabInfo, error) {
+	var tabs []TabInfo
+	err := c.callBrowser(browser, "QueryTabs", queryJSON).Store(&tabs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tabs: %w", err)
+	}
+
+	return tabs, nil
+}
+
+func (c *Client) MoveTabs(browser string, moves []TabMove) error {
+	var success bool
+	err := c.callBrowser(browser, "MoveTabs", moves).Store(&success)
+	if err != nil {
+		return fmt.Errorf("failed to move tabs: %w", err)
+	}
+	if !success {
+		return fmt.Errorf("tab move failed")
+	}
+
+	return nil
+}
+
+func (c *Client) UpdateTabs(browser string, updates []TabUpdate) ([]string, error) {
+	var tabIDs []string
+	err := c.callBrowser(browser, "UpdateTabs", updates).Store(&tabIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tabs: %w", err)
+	}
+
+	return tabIDs, nil
+}
+
+// OpenURLs opens urls in a window; see WindowCurrent and WindowNew.
+// Supported options are "active" (b) and "pinned" (b).
+func (c *Client) OpenURLs(browser string, urls []string, windowID int32, options map[string]dbus.Variant) ([]string, error) {
+	if options == nil {
+		options = map[string]dbus.Variant{}
+	}
+
+	var tabIDs []string
+	err := c.callBrowser(browser, "OpenURLs", urls, windowID, options).Store(&tabIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open URLs: %w", err)
+	}
 
-	obj := c.conn.Object(serviceName, objectPath)
+	return tabIDs, nil
+}
 
+func (c *Client) NewTab(browser, url string) (string, error) {
 	var tabID string
-	err := obj.Call(InterfaceBrowser+".OpenTab", 0, url).Store(&tabID)
+	err := c.callBrowser(browser, "NewTab", url).Store(&tabID)
 	if err != nil {
-		return "", fmt.Errorf("failed to open tab: %w", err)
+		return "", fmt.Errorf("failed to create tab: %w", err)
 	}
 
 	return tabID, nil
-}
\ No newline at end of file
+}
+
+func (c *Client) GetActiveTabs(browser string) ([]string, error) {
+	var tabIDs []string
+	err := c.callBrowser(browser, "GetActiveTabs").Store(&tabIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active tabs: %w", err)
+	}
+
+	return tabIDs, nil
+}
+
+// GetScreenshot captures the active tab of a window; WindowCurrent means the
+// last focused window
+func (c *Client) GetScreenshot(browser string, windowID int32) (Screenshot, error) {
+	var screenshot Screenshot
+	err := c.callBrowser(browser, "GetScreenshot", windowID).Store(&screenshot)
+	if err != nil {
+		return Screenshot{}, fmt.Errorf("failed to get screenshot: %w", err)
+	}
+
+	return screenshot, nil
+}
+
+// GetWords extracts words from a tab, or from every window's active tab when tabID is empty
+func (c *Client) GetWords(browser, tabID, matchRegex, joinWith string) ([]string, error) {
+	var words []string
+	err := c.callBrowser(browser, "GetWords", tabID, matchRegex, joinWith).Store(&words)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get words: %w", err)
+	}
+
+	return words, nil
+}
+
+func (c *Client) GetText(browser, delimiterRegex, replaceWith string) ([]TabContent, error) {
+	var content []TabContent
+	err := c.callBrowser(browser, "GetText", delimiterRegex, replaceWith).Store(&content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get text: %w", err)
+	}
+
+	return content, nil
+}
+
+func (c *Client) GetHTML(browser, delimiterRegex, replaceWith string) ([]TabContent, error) {
+	var content []TabContent
+	err := c.callBrowser(browser, "GetHTML", delimiterRegex, replaceWith).Store(&content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HTML: %w", err)
+	}
+
+	return content, nil
+}
+
+// IsUnknownMethod reports whether err means the mediator predates the method called
+func IsUnknownMethod(err error) bool {
+	var dbusErr dbus.Error
+	if stderrors.As(err, &dbusErr) {
+		return dbusErr.Name == "org.freedesktop.DBus.Error.UnknownMethod"
+	}
+	return false
+}
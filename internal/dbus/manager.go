@@ -0,0 +1,112 @@
+package dbus
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+// ManagerHandler serves the aggregated methods of the manager service
+type ManagerHandler interface {
+	ListBrowsers() ([]string, error)
+	ListAllTabs() ([]TabInfo, error)
+	ActivateTab(tabID string) error
+}
+
+// Manager exports the manager service at ServiceName(""). It uses a private
+// bus connection, so closing the shared connection of mediator clients in
+// the same process leaves the service registered.
+type Manager struct {
+	conn    *dbus.Conn
+	handler ManagerHandler
+}
+
+var _ ManagerServer = (*Manager)(nil)
+
+func NewManager(handler ManagerHandler) (*Manager, error) {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+
+	return &Manager{
+		conn:    conn,
+		handler: handler,
+	}, nil
+}
+
+func (m *Manager) Start() error {
+	serviceName := ServiceName("")
+	objectPath := ObjectPath("")
+
+	reply, err := m.conn.RequestName(serviceName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("failed to request name %s: %w", serviceName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("name %s already taken, is another daemon running?", serviceName)
+	}
+
+	if err := m.conn.Export(m, objectPath, InterfaceManager); err != nil {
+		return fmt.Errorf("failed to export object: %w", err)
+	}
+
+	err = m.conn.Export(introspect.Introspectable(generateManagerIntrospection()), objectPath,
+		"org.freedesktop.DBus.Introspectable")
+	if err != nil {
+		return fmt.Errorf("failed to export introspection: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Manager) Stop() error {
+	if m.conn != nil {
+		m.conn.ReleaseName(ServiceName(""))
+		return m.conn.Close()
+	}
+	return nil
+}
+
+// D-Bus method implementations
+func (m *Manager) ListBrowsers() ([]string, *dbus.Error) {
+	browsers, err := m.handler.ListBrowsers()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return browsers, nil
+}
+
+func (m *Manager) ListAllTabs() ([]TabInfo, *dbus.Error) {
+	tabs, err := m.handler.ListAllTabs()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return tabs, nil
+}
+
+func (m *Manager) ActivateTab(tabID string) (bool, *dbus.Error) {
+	if err := m.handler.ActivateTab(tabID); err != nil {
+		return false, dbus.MakeFailedError(err)
+	}
+	return true, nil
+}
+
+func generateManagerIntrospection() string {
+	return `
+<node>
+	<interface name="dev.slastra.TabCtl.Manager">
+		<method name="ListBrowsers">
+			<arg direction="out" type="as" name="browsers" />
+		</method>
+		<method name="ListAllTabs">
+			<arg direction="out" type="a(sssibb)" />
+		</method>
+		<method name="ActivateTab">
+			<arg direction="in" type="s" name="tab_id" />
+			<arg direction="out" type="b" name="success" />
+		</method>
+	</interface>
+</node>`
+}
@@ -8,6 +8,9 @@ import (
 	"github.com/godbus/dbus/v5/prop"
 )
 
+// maxInstances bounds how many mediators of one browser profile can coexist
+const maxInstances = 16
+
 type Server struct {
 	conn       *dbus.Conn
 	browser    string
@@ -20,6 +23,16 @@ type BrowserHandler interface {
 	ActivateTab(tabID string) error
 	CloseTab(tabID string) error
 	OpenTab(url string) (string, error)
+	QueryTabs(queryJSON string) ([]TabInfo, error)
+	MoveTabs(moves []TabMove) error
+	UpdateTabs(updates []TabUpdate) ([]string, error)
+	OpenURLs(urls []string, windowID int32, options map[string]dbus.Variant) ([]string, error)
+	NewTab(url string) (string, error)
+	GetActiveTabs() ([]string, error)
+	GetScreenshot(windowID int32) (Screenshot, error)
+	GetWords(tabID, matchRegex, joinWith string) ([]string, error)
+	GetText(delimiterRegex, replaceWith string) ([]TabContent, error)
+	GetHTML(delimiterRegex, replaceWith string) ([]TabContent, error)
 }
 
 func NewServer(browser string, handler BrowserHandler) (*Server, error) {
@@ -38,20 +51,15 @@ func NewServer(browser string, handler BrowserHandler) (*Server, error) {
 }
 
 func (s *Server) Start() error {
-	serviceName := ServiceName(s.browser)
-	objectPath := ObjectPath(s.browser)
-
-	// Request service name
-	reply, err := s.conn.RequestName(serviceName, dbus.NameFlagDoNotQueue)
-	if err != nil {
-		return fmt.Errorf("failed to request name %s: %w", serviceName, err)
-	}
-	if reply != dbus.RequestNameReplyPrimaryOwner {
-		return fmt.Errorf("name %s already taken", serviceName)
+	// Request service name, numbering further instances of the same browser
+	// profile ("Firefox#2") when the name is taken
+	if err := s.requestName(); err != nil {
+		return err
 	}
+	objectPath := ObjectPath(s.browser)
 
 	// Export methods
-	err = s.conn.Export(s, objectPath, InterfaceBrowser)
+	err := s.conn.Export(s, objectPath, InterfaceBrowser)
 	if err != nil {
 		return fmt.Errorf("failed to export object: %w", err)
 	}
@@ -85,6 +93,30 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// requestName claims the first free instance name of the browser
+func (s *Server) requestName() error {
+	for n := 1; n <= maxInstances; n++ {
+		name := InstanceName(s.browser, n)
+		serviceName := ServiceName(name)
+
+		reply, err := s.conn.RequestName(serviceName, dbus.NameFlagDoNotQueue)
+		if err != nil {
+			return fmt.Errorf("failed to request name %s: %w", serviceName, err)
+		}
+		if reply == dbus.RequestNameReplyPrimaryOwner {
+			s.browser = name
+			return nil
+		}
+	}
+	return fmt.Errorf("name %s already taken by %d instances", ServiceName(s.browser), maxInstances)
+}
+
+// Name returns the mediator name, including the instance number once the
+// server has started
+func (s *Server) Name() string {
+	return s.browser
+}
+
 func (s *Server) Stop() error {
 	if s.conn != nil {
 		serviceName := ServiceName(s.browser)
@@ -127,6 +159,123 @@ func (s *Server) OpenTab(url string) (string, *dbus.Error) {
 	return tabID, nil
 }
 
+func (s *Server) QueryTabs(queryJSON string) ([]TabInfo, *dbus.Error) {
+	tabs, err := s.handler.QueryTabs(queryJSON)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return tabs, nil
+}
+
+func (s *Server) MoveTabs(moves []TabMove) (bool, *dbus.Error) {
+	err := s.handler.MoveTabs(moves)
+	if err != nil {
+		return false, dbus.MakeFailedError(err)
+	}
+	return true, nil
+}
+
+func (s *Server) UpdateTabs(updates []TabUpdate) ([]string, *dbus.Error) {
+	tabIDs, err := s.handler.UpdateTabs(updates)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return tabIDs, nil
+}
+
+func (s *Server) OpenURLs(urls []string, windowID int32, options map[string]dbus.Variant) ([]string, *dbus.Error) {
+	tabIDs, err := s.handler.OpenURLs(urls, windowID, options)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return tabIDs, nil
+}
+
+func (s *Server) NewTab(url string) (string, *dbus.Error) {
+	tabID, err := s.handler.NewTab(url)
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return tabID, nil
+}
+
+func (s *Server) GetActiveTabs() ([]string, *dbus.Error) {
+	tabIDs, err := s.handler.GetActiveTabs()
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return tabIDs, nil
+}
+
+func (s *Server) GetScreenshot(windowID int32) (Screenshot, *dbus.Error) {
+	screenshot, err := s.handler.GetScreenshot(windowID)
+	if err != nil {
+		return Screenshot{}, dbus.MakeFailedError(err)
+	}
+	return screenshot, nil
+}
+
+func (s *Server) GetWords(tabID, matchRegex, joinWith string) ([]string, *dbus.Error) {
+	words, err := s.handler.GetWords(tabID, matchRegex, joinWith)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return words, nil
+}
+
+func (s *Server) GetText(delimiterRegex, replaceWith string) ([]TabContent, *dbus.Error) {
+	content, err := s.handler.GetText(delimiterRegex, replaceWith)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return content, nil
+}
+
+func (s *Server) GetHTML(delimiterRegex, replaceWith string) ([]TabContent, *dbus.Error) {
+	content, err := s.handler.GetHTML(delimiterRegex, replaceWith)
+	if err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+	return content, nil
+}
+
+// Signal emitters
+
+// emit sends a signal from the browser object on InterfaceBrowser
+func (s *Server) emit(signal string, values ...interface{}) error {
+	return s.conn.Emit(ObjectPath(s.browser), InterfaceBrowser+"."+signal, values...)
+}
+
+// EmitTabCreated announces a newly opened tab
+func (s *Server) EmitTabCreated(tab TabInfo) error {
+	return s.emit(SignalTabCreated, tab)
+}
+
+// EmitTabRemoved announces a closed tab
+func (s *Server) EmitTabRemoved(tabID string, windowID int32) error {
+	return s.emit(SignalTabRemoved, tabID, windowID)
+}
+
+// EmitTabUpdated announces a change to a tab's title, URL or state
+func (s *Server) EmitTabUpdated(tab TabInfo) error {
+	return s.emit(SignalTabUpdated, tab)
+}
+
+// EmitTabActivated announces that a tab became active in its window
+func (s *Server) EmitTabActivated(tabID string, windowID int32) error {
+	return s.emit(SignalTabActivated, tabID, windowID)
+}
+
+// EmitTabMoved announces a tab moving within or between windows
+func (s *Server) EmitTabMoved(tabID string, windowID, fromIndex, toIndex int32) error {
+	return s.emit(SignalTabMoved, tabID, windowID, fromIndex, toIndex)
+}
+
+// EmitWindowFocused announces a window gaining focus (-1 when none has focus)
+func (s *Server) EmitWindowFocused(windowID int32) error {
+	return s.emit(SignalWindowFocused, windowID)
+}
+
 func generateIntrospection() string {
 	return `
 <node>
@@ -146,6 +295,74 @@ func generateIntrospection() string {
 			<arg direction="in" type="s" name="url" />
 			<arg direction="out" type="s" name="tab_id" />
 		</method>
+		<method name="QueryTabs">
+			<arg direction="in" type="s" name="query_json" />
+			<arg direction="out" type="a(sssibb)" />
+		</method>
+		<method name="MoveTabs">
+			<arg direction="in" type="a(sii)" name="moves" />
+			<arg direction="out" type="b" name="success" />
+		</method>
+		<method name="UpdateTabs">
+			<arg direction="in" type="a(sa{sv})" name="updates" />
+			<arg direction="out" type="as" name="tab_ids" />
+		</method>
+		<method name="OpenURLs">
+			<arg direction="in" type="as" name="urls" />
+			<arg direction="in" type="i" name="window_id" />
+			<arg direction="in" type="a{sv}" name="options" />
+			<arg direction="out" type="as" name="tab_ids" />
+		</method>
+		<method name="NewTab">
+			<arg direction="in" type="s" name="url" />
+			<arg direction="out" type="s" name="tab_id" />
+		</method>
+		<method name="GetActiveTabs">
+			<arg direction="out" type="as" name="tab_ids" />
+		</method>
+		<method name="GetScreenshot">
+			<arg direction="in" type="i" name="window_id" />
+			<arg direction="out" type="(sii)" name="screenshot" />
+		</method>
+		<method name="GetWords">
+			<arg direction="in" type="s" name="tab_id" />
+			<arg direction="in" type="s" name="match_regex" />
+			<arg direction="in" type="s" name="join_with" />
+			<arg direction="out" type="as" name="words" />
+		</method>
+		<method name="GetText">
+			<arg direction="in" type="s" name="delimiter_regex" />
+			<arg direction="in" type="s" name="replace_with" />
+			<arg direction="out" type="a(ssss)" name="content" />
+		</method>
+		<method name="GetHTML">
+			<arg direction="in" type="s" name="delimiter_regex" />
+			<arg direction="in" type="s" name="replace_with" />
+			<arg direction="out" type="a(ssss)" name="content" />
+		</method>
+		<signal name="TabCreated">
+			<arg type="(sssibb)" name="tab" />
+		</signal>
+		<signal name="TabRemoved">
+			<arg type="s" name="tab_id" />
+			<arg type="i" name="window_id" />
+		</signal>
+		<signal name="TabUpdated">
+			<arg type="(sssibb)" name="tab" />
+		</signal>
+		<signal name="TabActivated">
+			<arg type="s" name="tab_id" />
+			<arg type="i" name="window_id" />
+		</signal>
+		<signal name="TabMoved">
+			<arg type="s" name="tab_id" />
+			<arg type="i" name="window_id" />
+			<arg type="i" name="from_index" />
+			<arg type="i" name="to_index" />
+		</signal>
+		<signal name="WindowFocused">
+			<arg type="i" name="window_id" />
+		</signal>
 		<property name="BrowserName" type="s" access="read" />
 	</interface>
 </node>`
@@ -1,6 +1,12 @@
 package dbus
 
-import "github.com/godbus/dbus/v5"
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/godbus/dbus/v5"
+)
 
 const (
 	ServiceNameBase = "dev.slastra.TabCtl"
@@ -8,6 +14,16 @@ const (
 	InterfaceManager = "dev.slastra.TabCtl.Manager"
 )
 
+// Signals emitted on InterfaceBrowser when the browser reports a change
+const (
+	SignalTabCreated    = "TabCreated"
+	SignalTabRemoved    = "TabRemoved"
+	SignalTabUpdated    = "TabUpdated"
+	SignalTabActivated  = "TabActivated"
+	SignalTabMoved      = "TabMoved"
+	SignalWindowFocused = "WindowFocused"
+)
+
 type TabInfo struct {
 	ID     string
 	Title  string
@@ -17,27 +33,203 @@ type TabInfo struct {
 	Pinned bool
 }
 
+// TabContent is the extracted text or HTML of a tab, signature (ssss)
+type TabContent struct {
+	ID      string
+	Title   string
+	URL     string
+	Content string
+}
+
+// TabMove moves a tab to an index in a window, signature (sii)
+type TabMove struct {
+	TabID    string
+	WindowID int32
+	Index    int32
+}
+
+// TabUpdate sets tab properties such as url, active, pinned or muted, signature (sa{sv})
+type TabUpdate struct {
+	TabID      string
+	Properties map[string]dbus.Variant
+}
+
+// Screenshot is a capture of the visible tab as a data URL, signature (sii)
+type Screenshot struct {
+	Data     string
+	TabID    int32
+	WindowID int32
+}
+
+// WindowCurrent and WindowNew are special window IDs accepted by OpenURLs.
+// GetScreenshot accepts WindowCurrent for the last focused window.
+const (
+	WindowCurrent int32 = -1
+	WindowNew     int32 = 0
+)
+
 type BrowserServer interface {
 	ListTabs() ([]TabInfo, *dbus.Error)
 	ActivateTab(tabID string) (bool, *dbus.Error)
 	CloseTab(tabID string) (bool, *dbus.Error)
 	OpenTab(url string) (string, *dbus.Error)
+	QueryTabs(queryJSON string) ([]TabInfo, *dbus.Error)
+	MoveTabs(moves []TabMove) (bool, *dbus.Error)
+	UpdateTabs(updates []TabUpdate) ([]string, *dbus.Error)
+	OpenURLs(urls []string, windowID int32, options map[string]dbus.Variant) ([]string, *dbus.Error)
+	NewTab(url string) (string, *dbus.Error)
+	GetActiveTabs() ([]string, *dbus.Error)
+	GetScreenshot(windowID int32) (Screenshot, *dbus.Error)
+	GetWords(tabID, matchRegex, joinWith string) ([]string, *dbus.Error)
+	GetText(delimiterRegex, replaceWith string) ([]TabContent, *dbus.Error)
+	GetHTML(delimiterRegex, replaceWith string) ([]TabContent, *dbus.Error)
 }
 
 type ManagerServer interface {
 	ListBrowsers() ([]string, *dbus.Error)
+	ListAllTabs() ([]TabInfo, *dbus.Error)
+	ActivateTab(tabID string) (bool, *dbus.Error)
+}
+
+// Mediator names are "<Browser>" for the default profile of a browser and
+// "<Browser>:<profile>" otherwise. Further mediators of the same browser
+// profile append an instance number: "Firefox#2", "Firefox:work#2". On the
+// bus the profile and the instance are extra name elements: "Brave:work#2"
+// owns dev.slastra.TabCtl.Brave.work.i2 and exports
+// /dev/slastra/TabCtl/Browser/Brave/work/i2.
+
+// BrowserInstance describes a mediator found on the bus
+type BrowserInstance struct {
+	Name     string // unique mediator name, also the instance ID
+	Browser  string // product name: Firefox, Brave, ...
+	Profile  string // empty for the default profile
+	Instance int    // 1 for the first mediator of a browser profile
+	Service  string // bus name
+}
+
+// ParseBrowserName splits a mediator name into its parts
+func ParseBrowserName(name string) BrowserInstance {
+	instance := splitName(name)
+	instance.Service = ServiceName(name)
+	return instance
+}
+
+// splitName splits a mediator name into browser, profile and instance
+func splitName(name string) BrowserInstance {
+	instance := 1
+	base := name
+	if i := strings.LastIndex(name, "#"); i >= 0 {
+		if n, err := strconv.Atoi(name[i+1:]); err == nil && n > 1 {
+			base, instance = name[:i], n
+		}
+	}
+
+	browser, profile, _ := strings.Cut(base, ":")
+	return BrowserInstance{
+		Name:     name,
+		Browser:  browser,
+		Profile:  profile,
+		Instance: instance,
+	}
 }
 
+// InstanceName returns the name of the nth mediator of a browser profile
+func InstanceName(name string, n int) string {
+	if n <= 1 {
+		return name
+	}
+	return name + "#" + strconv.Itoa(n)
+}
+
+// ServiceName returns the bus name of a mediator, or of the manager when
+// browser is empty
 func ServiceName(browser string) string {
 	if browser == "" {
 		return ServiceNameBase
 	}
-	return ServiceNameBase + "." + browser
+	return ServiceNameBase + "." + nameElements(browser, ".")
 }
 
+// ObjectPath returns the object path of a mediator, or of the manager when
+// browser is empty
 func ObjectPath(browser string) dbus.ObjectPath {
 	if browser == "" {
 		return "/dev/slastra/TabCtl/Manager"
 	}
-	return dbus.ObjectPath("/dev/slastra/TabCtl/Browser/" + browser)
+	return dbus.ObjectPath(browserPathPrefix + nameElements(browser, "/"))
+}
+
+// browserPathPrefix is the object path prefix every mediator exports under
+const browserPathPrefix = "/dev/slastra/TabCtl/Browser/"
+
+// nameElements converts a mediator name into bus name elements joined by sep
+func nameElements(browser, sep string) string {
+	parsed := splitName(browser)
+
+	elements := []string{SanitizeNameElement(parsed.Browser)}
+	if parsed.Profile != "" {
+		elements = append(elements, ProfileElement(parsed.Profile))
+	}
+	if parsed.Instance > 1 {
+		elements = append(elements, "i"+strconv.Itoa(parsed.Instance))
+	}
+	return strings.Join(elements, sep)
+}
+
+// browserFromElements converts bus name elements joined by sep back into a
+// mediator name
+func browserFromElements(elements, sep string) string {
+	parts := strings.Split(elements, sep)
+
+	instance := 1
+	if last := parts[len(parts)-1]; len(parts) > 1 && isInstanceElement(last) {
+		instance, _ = strconv.Atoi(last[1:])
+		parts = parts[:len(parts)-1]
+	}
+
+	name := parts[0]
+	if len(parts) > 1 {
+		name += ":" + strings.Join(parts[1:], "_")
+	}
+	return InstanceName(name, instance)
+}
+
+// isInstanceElement reports whether a name element is an instance number
+// ("i2")
+func isInstanceElement(element string) bool {
+	if len(element) < 2 || element[0] != 'i' {
+		return false
+	}
+	_, err := strconv.Atoi(element[1:])
+	return err == nil && element[1] != '-' && element[1] != '+'
+}
+
+// ProfileElement returns the bus name element of a profile. A profile such
+// as "i2" is kept apart from an instance number as "_i2". Mediator names
+// built from it come back unchanged from their bus name.
+func ProfileElement(profile string) string {
+	element := SanitizeNameElement(profile)
+	if isInstanceElement(element) {
+		element = "_" + element
+	}
+	return element
+}
+
+// SanitizeNameElement makes s usable as a single bus name or object path
+// element, which may only contain [A-Za-z0-9_] and must not start with a digit
+func SanitizeNameElement(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+
+	element := b.String()
+	if element == "" || unicode.IsDigit(rune(element[0])) {
+		element = "_" + element
+	}
+	return element
 }
\ No newline at end of file
@@ -0,0 +1,112 @@
+package dbus
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMediatorNamesOnTheBus(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		path    string
+		parsed  BrowserInstance
+	}{
+		{"Firefox", "dev.slastra.TabCtl.Firefox", "/dev/slastra/TabCtl/Browser/Firefox",
+			BrowserInstance{Browser: "Firefox", Instance: 1}},
+		{"Firefox#2", "dev.slastra.TabCtl.Firefox.i2", "/dev/slastra/TabCtl/Browser/Firefox/i2",
+			BrowserInstance{Browser: "Firefox", Instance: 2}},
+		{"Brave:work", "dev.slastra.TabCtl.Brave.work", "/dev/slastra/TabCtl/Browser/Brave/work",
+			BrowserInstance{Browser: "Brave", Profile: "work", Instance: 1}},
+		{"Brave:work#12", "dev.slastra.TabCtl.Brave.work.i12", "/dev/slastra/TabCtl/Browser/Brave/work/i12",
+			BrowserInstance{Browser: "Brave", Profile: "work", Instance: 12}},
+		{"Chrome:Profile_1", "dev.slastra.TabCtl.Chrome.Profile_1", "/dev/slastra/TabCtl/Browser/Chrome/Profile_1",
+			BrowserInstance{Browser: "Chrome", Profile: "Profile_1", Instance: 1}},
+		// A profile looking like an instance number is escaped
+		{"Chrome:_i2", "dev.slastra.TabCtl.Chrome._i2", "/dev/slastra/TabCtl/Browser/Chrome/_i2",
+			BrowserInstance{Browser: "Chrome", Profile: "_i2", Instance: 1}},
+		{"Chrome:_i2#3", "dev.slastra.TabCtl.Chrome._i2.i3", "/dev/slastra/TabCtl/Browser/Chrome/_i2/i3",
+			BrowserInstance{Browser: "Chrome", Profile: "_i2", Instance: 3}},
+		{"Zen:_2024", "dev.slastra.TabCtl.Zen._2024", "/dev/slastra/TabCtl/Browser/Zen/_2024",
+			BrowserInstance{Browser: "Zen", Profile: "_2024", Instance: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ServiceName(tt.name); got != tt.service {
+				t.Errorf("ServiceName = %s, want %s", got, tt.service)
+			}
+			if got := ObjectPath(tt.name); string(got) != tt.path {
+				t.Errorf("ObjectPath = %s, want %s", got, tt.path)
+			}
+
+			// Discovery reads the name back from the bus name and the path
+			elements := strings.TrimPrefix(tt.service, ServiceNameBase+".")
+			if got := browserFromElements(elements, "."); got != tt.name {
+				t.Errorf("name from bus name = %s, want %s", got, tt.name)
+			}
+			path := strings.TrimPrefix(tt.path, browserPathPrefix)
+			if got := browserFromElements(path, "/"); got != tt.name {
+				t.Errorf("name from object path = %s, want %s", got, tt.name)
+			}
+
+			want := tt.parsed
+			want.Name, want.Service = tt.name, tt.service
+			if got := ParseBrowserName(tt.name); got != want {
+				t.Errorf("ParseBrowserName = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestManagerName(t *testing.T) {
+	if got := ServiceName(""); got != ServiceNameBase {
+		t.Errorf("ServiceName(\"\") = %s, want %s", got, ServiceNameBase)
+	}
+	if got := ObjectPath(""); got != "/dev/slastra/TabCtl/Manager" {
+		t.Errorf("ObjectPath(\"\") = %s", got)
+	}
+}
+
+func TestParseBrowserNameInstances(t *testing.T) {
+	tests := []struct {
+		name     string
+		browser  string
+		profile  string
+		instance int
+	}{
+		{"Firefox#1", "Firefox#1", "", 1}, // not an instance number
+		{"Firefox#0", "Firefox#0", "", 1},
+		{"Firefox#x", "Firefox#x", "", 1},
+		{"Firefox:a:b", "Firefox", "a:b", 1},
+		{"Firefox:work#2", "Firefox", "work", 2},
+	}
+	for _, tt := range tests {
+		got := ParseBrowserName(tt.name)
+		if got.Browser != tt.browser || got.Profile != tt.profile || got.Instance != tt.instance {
+			t.Errorf("ParseBrowserName(%q) = %+v, want %s, %q, %d", tt.name, got, tt.browser, tt.profile, tt.instance)
+		}
+	}
+}
+
+func TestElements(t *testing.T) {
+	tests := []struct{ in, element, profile string }{
+		{"work", "work", "work"},
+		{"my profile", "my_profile", "my_profile"},
+		{"Profile-1", "Profile_1", "Profile_1"},
+		{"2024", "_2024", "_2024"},
+		{"i2", "i2", "_i2"},
+		{"i", "i", "i"},
+		{"i-2", "i_2", "i_2"},
+		{"café", "caf_", "caf_"},
+		{"", "_", "_"},
+	}
+	for _, tt := range tests {
+		if got := SanitizeNameElement(tt.in); got != tt.element {
+			t.Errorf("SanitizeNameElement(%q) = %q, want %q", tt.in, got, tt.element)
+		}
+		if got := ProfileElement(tt.in); got != tt.profile {
+			t.Errorf("ProfileElement(%q) = %q, want %q", tt.in, got, tt.profile)
+		}
+	}
+}
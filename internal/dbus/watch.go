@@ -0,0 +1,145 @@
+package dbus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Pseudo-signals reported when a mediator joins or leaves the bus
+const (
+	SignalBrowserConnected    = "BrowserConnected"
+	SignalBrowserDisconnected = "BrowserDisconnected"
+)
+
+// Event is a signal received from a mediator, or a mediator's arrival or
+// departure on the bus. Fields not carried by the signal are left zero.
+type Event struct {
+	Browser   string
+	Signal    string
+	TabID     string
+	WindowID  int32
+	FromIndex int32
+	ToIndex   int32
+	Tab       *TabInfo // set for TabCreated and TabUpdated
+}
+
+// WatchEvents subscribes to the signals of every mediator, including ones
+// that start after the call, until ctx is done. The returned channel is
+// closed when the subscription ends.
+func (c *Client) WatchEvents(ctx context.Context) (<-chan Event, error) {
+	browserMatch := []dbus.MatchOption{
+		dbus.WithMatchInterface(InterfaceBrowser),
+	}
+	ownerMatch := []dbus.MatchOption{
+		dbus.WithMatchSender("org.freedesktop.DBus"),
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg0Namespace(ServiceNameBase),
+	}
+
+	if err := c.conn.AddMatchSignal(browserMatch...); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to browser signals: %w", err)
+	}
+	if err := c.conn.AddMatchSignal(ownerMatch...); err != nil {
+		c.conn.RemoveMatchSignal(browserMatch...)
+		return nil, fmt.Errorf("failed to subscribe to name changes: %w", err)
+	}
+
+	signals := make(chan *dbus.Signal, 64)
+	c.conn.Signal(signals)
+
+	events := make(chan Event, 64)
+	go func() {
+		defer close(events)
+		defer func() {
+			c.conn.RemoveSignal(signals)
+			c.conn.RemoveMatchSignal(browserMatch...)
+			c.conn.RemoveMatchSignal(ownerMatch...)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig, ok := <-signals:
+				if !ok {
+					return
+				}
+				event, ok := parseSignal(sig)
+				if !ok {
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// parseSignal converts a raw D-Bus signal into an Event
+func parseSignal(sig *dbus.Signal) (Event, bool) {
+	if sig.Name == "org.freedesktop.DBus.NameOwnerChanged" {
+		return parseNameOwnerChanged(sig.Body)
+	}
+
+	if !strings.HasPrefix(sig.Name, InterfaceBrowser+".") {
+		return Event{}, false
+	}
+
+	event := Event{
+		Browser: browserFromElements(strings.TrimPrefix(string(sig.Path), browserPathPrefix), "/"),
+		Signal:  strings.TrimPrefix(sig.Name, InterfaceBrowser+"."),
+	}
+
+	var err error
+	switch event.Signal {
+	case SignalTabCreated, SignalTabUpdated:
+		var tab TabInfo
+		err = dbus.Store(sig.Body, &tab)
+		event.Tab = &tab
+		event.TabID = tab.ID
+	case SignalTabRemoved, SignalTabActivated:
+		err = dbus.Store(sig.Body, &event.TabID, &event.WindowID)
+	case SignalTabMoved:
+		err = dbus.Store(sig.Body, &event.TabID, &event.WindowID, &event.FromIndex, &event.ToIndex)
+	case SignalWindowFocused:
+		err = dbus.Store(sig.Body, &event.WindowID)
+	default:
+		return Event{}, false
+	}
+	if err != nil {
+		return Event{}, false
+	}
+
+	return event, true
+}
+
+// parseNameOwnerChanged reports mediators acquiring or losing their bus name
+func parseNameOwnerChanged(body []interface{}) (Event, bool) {
+	var name, oldOwner, newOwner string
+	if err := dbus.Store(body, &name, &oldOwner, &newOwner); err != nil {
+		return Event{}, false
+	}
+
+	elements := strings.TrimPrefix(name, ServiceNameBase+".")
+	if elements == name || elements == "" || elements == "Manager" {
+		return Event{}, false
+	}
+	browser := browserFromElements(elements, ".")
+
+	switch {
+	case newOwner != "":
+		return Event{Browser: browser, Signal: SignalBrowserConnected}, true
+	case oldOwner != "":
+		return Event{Browser: browser, Signal: SignalBrowserDisconnected}, true
+	}
+	return Event{}, false
+}
@@ -0,0 +1,376 @@
+// Package filter implements the tab filter expressions accepted by --filter.
+//
+// An expression combines terms with "and", "or", "not" and parentheses;
+// adjacent terms without an operator are joined with "and":
+//
+//	domain:github.com and not pinned and title~"PR #\d+"
+//
+// A term is either a flag (pinned, active), a bare word or quoted string
+// matched against the title and URL, or a field with an operator:
+//
+//	field:value   contains (title, url), host or subdomain (domain), equals (others)
+//	field=value   equals, ignoring case
+//	field~regex   matches the regular expression
+//
+// Fields: title, url, domain, id, window, prefix, index. A word whose part
+// before the operator is no field, such as https://example.com, is a bare
+// word.
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// Predicate reports whether a tab matches a filter
+type Predicate func(tab types.Tab) bool
+
+// Parse compiles a filter expression into a predicate
+func Parse(expr string) (Predicate, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokEOF {
+		return nil, fmt.Errorf("empty filter")
+	}
+
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("invalid filter: unexpected %q at position %d", tok.text, tok.pos+1)
+	}
+	return pred, nil
+}
+
+// Tabs returns the tabs matching pred, in order
+func Tabs(tabs []types.Tab, pred Predicate) []types.Tab {
+	var matched []types.Tab
+	for _, tab := range tabs {
+		if pred(tab) {
+			matched = append(matched, tab)
+		}
+	}
+	return matched
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokTerm
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // source text, for error messages
+	field string // empty for bare terms
+	op    byte   // ':', '=' or '~'; zero for bare terms
+	value string
+	pos   int
+}
+
+// lex splits an expression into tokens
+func lex(expr string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+		case c == '"':
+			value, end, err := lexQuoted(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokTerm, text: expr[i:end], value: value, pos: i})
+			i = end
+		default:
+			tok, end, err := lexWord(expr, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = end
+		}
+	}
+	return append(tokens, token{kind: tokEOF, text: "end of filter", pos: len(expr)}), nil
+}
+
+// lexWord reads a keyword, a bare word or a field term starting at start
+func lexWord(expr string, start int) (token, int, error) {
+	i := start
+	for i < len(expr) && !isWordEnd(expr[i]) && !isOperator(expr[i]) {
+		i++
+	}
+	word := expr[start:i]
+
+	if i < len(expr) && isOperator(expr[i]) {
+		op := expr[i]
+		field := strings.ToLower(word)
+		if _, ok := fields[field]; !ok {
+			// Not a field, such as a URL: the whole word is a bare term
+			for i < len(expr) && !isWordEnd(expr[i]) {
+				i++
+			}
+			return token{kind: tokTerm, text: expr[start:i], value: expr[start:i], pos: start}, i, nil
+		}
+
+		i++
+		var value string
+		if i < len(expr) && expr[i] == '"' {
+			var err error
+			value, i, err = lexQuoted(expr, i)
+			if err != nil {
+				return token{}, 0, err
+			}
+		} else {
+			valueStart := i
+			for i < len(expr) && !isWordEnd(expr[i]) {
+				i++
+			}
+			value = expr[valueStart:i]
+		}
+		if value == "" {
+			return token{}, 0, fmt.Errorf("invalid filter: missing value for %s%c at position %d", word, op, start+1)
+		}
+		return token{kind: tokTerm, text: expr[start:i], field: field, op: op, value: value, pos: start}, i, nil
+	}
+
+	tok := token{kind: tokTerm, text: word, value: word, pos: start}
+	switch strings.ToLower(word) {
+	case "and":
+		tok.kind = tokAnd
+	case "or":
+		tok.kind = tokOr
+	case "not":
+		tok.kind = tokNot
+	}
+	return tok, i, nil
+}
+
+// lexQuoted reads a double-quoted string starting at start. Only \" is an
+// escape; other backslashes are kept so regular expressions need no doubling.
+func lexQuoted(expr string, start int) (string, int, error) {
+	var b strings.Builder
+	for i := start + 1; i < len(expr); i++ {
+		switch {
+		case expr[i] == '\\' && i+1 < len(expr) && expr[i+1] == '"':
+			b.WriteByte('"')
+			i++
+		case expr[i] == '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(expr[i])
+		}
+	}
+	return "", 0, fmt.Errorf("invalid filter: unterminated string at position %d", start+1)
+}
+
+func isWordEnd(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '(' || c == ')'
+}
+
+func isOperator(c byte) bool {
+	return c == ':' || c == '=' || c == '~'
+}
+
+// parser is a recursive descent parser over the tokens:
+//
+//	or    = and { "or" and }
+//	and   = unary { ["and"] unary }
+//	unary = "not" unary | "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tab types.Tab) bool { return l(tab) || right(tab) }
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokNot, tokLParen, tokTerm:
+			// Implicit "and"
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(tab types.Tab) bool { return l(tab) && right(tab) }
+	}
+}
+
+func (p *parser) parseUnary() (Predicate, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNot:
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(tab types.Tab) bool { return !operand(tab) }, nil
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("invalid filter: expected ) at position %d", closing.pos+1)
+		}
+		return inner, nil
+	case tokTerm:
+		return compileTerm(tok)
+	default:
+		return nil, fmt.Errorf("invalid filter: unexpected %s at position %d", quoteToken(tok), tok.pos+1)
+	}
+}
+
+func quoteToken(tok token) string {
+	if tok.kind == tokEOF {
+		return tok.text
+	}
+	return strconv.Quote(tok.text)
+}
+
+// flags are the boolean tab properties usable as bare terms
+var flags = map[string]Predicate{
+	"pinned": func(tab types.Tab) bool { return tab.Pinned },
+	"active": func(tab types.Tab) bool { return tab.Active },
+}
+
+// fields extract the value a field term is matched against
+var fields = map[string]func(tab types.Tab) string{
+	"title":  func(tab types.Tab) string { return tab.Title },
+	"url":    func(tab types.Tab) string { return tab.URL },
+	"domain": func(tab types.Tab) string { return hostname(tab.URL) },
+	"id":     func(tab types.Tab) string { return tab.ID },
+	"window": func(tab types.Tab) string {
+		return utils.GetTabPrefix(tab.ID) + strconv.Itoa(tab.WindowID)
+	},
+	"prefix": func(tab types.Tab) string { return strings.TrimSuffix(utils.GetTabPrefix(tab.ID), ".") },
+	"index":  func(tab types.Tab) string { return strconv.Itoa(tab.Index) },
+}
+
+// compileTerm turns a term token into a predicate
+func compileTerm(tok token) (Predicate, error) {
+	if tok.field == "" {
+		if flag, ok := flags[strings.ToLower(tok.value)]; ok && !strings.HasPrefix(tok.text, `"`) {
+			return flag, nil
+		}
+		needle := strings.ToLower(tok.value)
+		return func(tab types.Tab) bool {
+			return strings.Contains(strings.ToLower(tab.Title), needle) ||
+				strings.Contains(strings.ToLower(tab.URL), needle)
+		}, nil
+	}
+
+	get := fields[tok.field]
+	switch tok.op {
+	case '~':
+		re, err := regexp.Compile(tok.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: bad regular expression in %s: %w", tok.text, err)
+		}
+		return func(tab types.Tab) bool { return re.MatchString(get(tab)) }, nil
+	case '=':
+		return equalsTerm(tok.field, tok.value, get), nil
+	}
+
+	// ':' depends on the field
+	switch tok.field {
+	case "title", "url":
+		needle := strings.ToLower(tok.value)
+		return func(tab types.Tab) bool { return strings.Contains(strings.ToLower(get(tab)), needle) }, nil
+	case "domain":
+		domain := strings.ToLower(strings.TrimPrefix(tok.value, "."))
+		return func(tab types.Tab) bool {
+			host := get(tab)
+			return host == domain || strings.HasSuffix(host, "."+domain)
+		}, nil
+	default:
+		return equalsTerm(tok.field, tok.value, get), nil
+	}
+}
+
+// equalsTerm matches the field exactly, ignoring case. A window without a
+// prefix ("window:3") matches that window ID in every browser, and a prefix
+// may be given with its trailing dot.
+func equalsTerm(field, value string, get func(types.Tab) string) Predicate {
+	switch field {
+	case "window":
+		if !strings.Contains(value, ".") {
+			return func(tab types.Tab) bool { return strconv.Itoa(tab.WindowID) == value }
+		}
+	case "prefix":
+		value = strings.TrimSuffix(value, ".")
+	}
+	return func(tab types.Tab) bool { return strings.EqualFold(get(tab), value) }
+}
+
+// hostname returns the lowercase host of a URL, or "" if it has none
+func hostname(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
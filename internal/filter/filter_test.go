@@ -0,0 +1,124 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// testTabs are matched by every case below, by ID
+var testTabs = []types.Tab{
+	{ID: "f.1.10", Title: "PR #42: fix parser", URL: "https://github.com/tabctl/tabctl/pull/42", WindowID: 1, Index: 0, Pinned: true},
+	{ID: "f.1.11", Title: "Issues", URL: "https://gist.github.com/x", WindowID: 1, Index: 1, Active: true},
+	{ID: "f.2.20", Title: "Watch \"later\"", URL: "https://www.youtube.com/watch?v=1", WindowID: 2, Index: 0, Active: true},
+	{ID: "c.1.30", Title: "Docs manual", URL: "https://docs.example.com/manual", WindowID: 1, Index: 0},
+	{ID: "c.1.31", Title: "notgithub", URL: "https://notgithub.com/", WindowID: 1, Index: 1, Pinned: true},
+}
+
+func matchIDs(t *testing.T, expr string) []string {
+	t.Helper()
+	pred, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	var ids []string
+	for _, tab := range Tabs(testTabs, pred) {
+		ids = append(ids, tab.ID)
+	}
+	return ids
+}
+
+func TestParseMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want []string
+	}{
+		// Flags and bare words
+		{"flag pinned", "pinned", []string{"f.1.10", "c.1.31"}},
+		{"flag active", "ACTIVE", []string{"f.1.11", "f.2.20"}},
+		{"bare word title or url", "manual", []string{"c.1.30"}},
+		{"bare word ignores case", "ISSUES", []string{"f.1.11"}},
+		{"quoted flag is a word", `"pinned"`, nil},
+		{"quoted string with spaces", `"fix parser"`, []string{"f.1.10"}},
+		{"quoted string escape", `"watch \"later\""`, []string{"f.2.20"}},
+
+		// Precedence: not > and > or, implicit and, parentheses
+		{"and binds tighter than or", "pinned and github or youtube", []string{"f.1.10", "f.2.20", "c.1.31"}},
+		{"or then and", "youtube or pinned and domain:github.com", []string{"f.1.10", "f.2.20"}},
+		{"parentheses", "(youtube or pinned) and active", []string{"f.2.20"}},
+		{"implicit and", "pinned github", []string{"f.1.10", "c.1.31"}},
+		{"not binds to its term", "not pinned and active", []string{"f.1.11", "f.2.20"}},
+		{"not of group", "not (pinned or active)", []string{"c.1.30"}},
+		{"double not", "not not pinned", []string{"f.1.10", "c.1.31"}},
+		{"keywords ignore case", "PINNED AND NOT Active", []string{"f.1.10", "c.1.31"}},
+
+		// title and url: contains
+		{"title contains", "title:parser", []string{"f.1.10"}},
+		{"title quoted", `title:"fix parser"`, []string{"f.1.10"}},
+		{"url contains", "url:docs.", []string{"c.1.30"}},
+		{"title equals", "title=issues", []string{"f.1.11"}},
+		{"title equals is not contains", "title=issue", nil},
+		{"title regex", `title~"PR #\d+"`, []string{"f.1.10"}},
+
+		// domain: host or subdomain
+		{"domain and subdomains", "domain:github.com", []string{"f.1.10", "f.1.11"}},
+		{"domain leading dot", "domain:.github.com", []string{"f.1.10", "f.1.11"}},
+		{"domain equals", "domain=github.com", []string{"f.1.10"}},
+		{"domain case", "domain:YOUTUBE.com", []string{"f.2.20"}},
+		{"domain regex", "domain~^docs", []string{"c.1.30"}},
+
+		// id, window, prefix, index
+		{"id", "id:f.1.11", []string{"f.1.11"}},
+		{"id regex", `id~^c\.`, []string{"c.1.30", "c.1.31"}},
+		{"window with prefix", "window:f.1", []string{"f.1.10", "f.1.11"}},
+		{"window in every browser", "window:1", []string{"f.1.10", "f.1.11", "c.1.30", "c.1.31"}},
+		{"prefix", "prefix:c", []string{"c.1.30", "c.1.31"}},
+		{"prefix with dot", "prefix=f.", []string{"f.1.10", "f.1.11", "f.2.20"}},
+		{"index", "index:1", []string{"f.1.11", "c.1.31"}},
+		{"field names ignore case", "Prefix:c and Index:0", []string{"c.1.30"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchIDs(t, tt.expr)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Parse(%q) matched %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "empty filter"},
+		{"   ", "empty filter"},
+		{"pinned and", "unexpected end of filter at position 11"},
+		{"or pinned", `unexpected "or" at position 1`},
+		{"(pinned", "expected ) at position 8"},
+		{"pinned)", `unexpected ")" at position 7`},
+		{"()", `unexpected ")" at position 2`},
+		{"not", "unexpected end of filter at position 4"},
+		{"pinned title:", "missing value for title: at position 8"},
+		{`"open`, "unterminated string at position 1"},
+		{`title:"open`, "unterminated string at position 7"},
+		{`title~"("`, `bad regular expression in title~"("`},
+		{"title~(", "missing value for title~ at position 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			if err == nil {
+				t.Fatalf("Parse(%q) succeeded, want error %q", tt.expr, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %q, want it to contain %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,14 @@
 package mediator
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/tabctl/tabctl/internal/config"
 	"github.com/tabctl/tabctl/internal/errors"
 )
 
@@ -12,6 +16,7 @@ import (
 type BrowserAPI struct {
 	transport Transport
 	browser   string
+	timeouts  map[string]time.Duration
 }
 
 // NewBrowserAPI creates a new browser API with the specified browser name
@@ -19,18 +24,47 @@ func NewBrowserAPI(transport Transport, browser string) *BrowserAPI {
 	return &BrowserAPI{
 		transport: transport,
 		browser:   browser,
+		timeouts: map[string]time.Duration{
+			CmdGetBrowser:    config.BrowserQueryTimeout,
+			CmdGetScreenshot: config.ScreenshotTimeout,
+			CmdGetHTML:       config.ContentTimeout,
+			CmdGetText:       config.ContentTimeout,
+			CmdGetWords:      config.ContentTimeout,
+		},
 	}
 }
 
+// SetTimeout overrides how long to wait for the response to the named command.
+// A zero duration disables the deadline. Not safe to call while serving requests.
+func (r *BrowserAPI) SetTimeout(command string, timeout time.Duration) {
+	r.timeouts[command] = timeout
+}
+
+// timeoutFor returns the response deadline for the named command
+func (r *BrowserAPI) timeoutFor(command string) time.Duration {
+	if timeout, ok := r.timeouts[command]; ok {
+		return timeout
+	}
+	return config.TransportTimeout
+}
+
 // sendCommand sends a command to the browser and returns the response.
+// It gives up with a TimeoutError once the command's deadline passes.
 func (r *BrowserAPI) sendCommand(cmd *Command) (interface{}, error) {
-	// Sending command to browser
-	if err := r.transport.Send(cmd); err != nil {
-		return nil, err
+	ctx := context.Background()
+	timeout := r.timeoutFor(cmd.Command)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	response, err := r.transport.Recv()
+	// Send command and wait for the response carrying its ID
+	response, err := r.transport.Request(ctx, cmd)
 	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, errors.NewTimeoutError(cmd.Command, timeout.String())
+		}
 		return nil, err
 	}
 
@@ -45,7 +79,6 @@ func (r *BrowserAPI) sendCommand(cmd *Command) (interface{}, error) {
 	return result, nil
 }
 
-
 // ListTabs returns a list of all tabs
 func (r *BrowserAPI) ListTabs() ([]string, error) {
 	cmd := NewCommand(CmdListTabs, nil)
@@ -101,8 +134,9 @@ func (r *BrowserAPI) QueryTabs(queryInfo string) ([]string, error) {
 	return nil, errors.NewTransportError("unexpected response format", nil)
 }
 
-// MoveTabs moves tabs according to the given triplets
-func (r *BrowserAPI) MoveTabs(moveTriplets string) (string, error) {
+// MoveTabs moves tabs according to the given (tab ID, window ID, index) triplets.
+// The extension applies them one after another in order.
+func (r *BrowserAPI) MoveTabs(moveTriplets [][3]int) (string, error) {
 	cmd := NewCommand(CmdMoveTabs, map[string]interface{}{
 		"move_triplets": moveTriplets,
 	})
@@ -119,14 +153,19 @@ func (r *BrowserAPI) MoveTabs(moveTriplets string) (string, error) {
 	return "OK", nil
 }
 
-// OpenURLs opens the given URLs
-func (r *BrowserAPI) OpenURLs(urls []string, windowID *int) ([]string, error) {
+// OpenURLs opens the given URLs. A nil windowID opens them in the current
+// window and a window ID of 0 opens them in a new window. options may set
+// "active" and "pinned" for the new tabs.
+func (r *BrowserAPI) OpenURLs(urls []string, windowID *int, options map[string]interface{}) ([]string, error) {
 	args := map[string]interface{}{
 		"urls": urls,
 	}
 	if windowID != nil {
 		args["window_id"] = *windowID
 	}
+	if len(options) > 0 {
+		args["options"] = options
+	}
 
 	cmd := NewCommand(CmdOpenURLs, args)
 	result, err := r.sendCommand(cmd)
@@ -199,9 +238,13 @@ func (r *BrowserAPI) NewTab(query string) (string, error) {
 		return "", fmt.Errorf("failed to communicate with browser extension: %w", err)
 	}
 
+	// Firefox and Chrome reply with a one-element array, older builds with a string
 	if tabID, ok := result.(string); ok {
 		return tabID, nil
 	}
+	if ids, err := r.parseStringArray(result, "new tab"); err == nil && len(ids) > 0 {
+		return ids[0], nil
+	}
 
 	return "", errors.NewTransportError("unexpected response format", nil)
 }
@@ -236,29 +279,50 @@ func (r *BrowserAPI) GetActiveTabs() (string, error) {
 	return "", errors.NewTransportError("unexpected response format", nil)
 }
 
-// GetScreenshot captures a screenshot
-func (r *BrowserAPI) GetScreenshot() (string, error) {
-	cmd := NewCommand(CmdGetScreenshot, nil)
+// GetScreenshot captures the visible area of the active tab in a window, or
+// in the last focused window when windowID is nil
+func (r *BrowserAPI) GetScreenshot(windowID *int) (*Screenshot, error) {
+	args := map[string]interface{}{}
+	if windowID != nil {
+		args["window_id"] = *windowID
+	}
+
+	cmd := NewCommand(CmdGetScreenshot, args)
 	result, err := r.sendCommand(cmd)
 	if err != nil {
-		return "", fmt.Errorf("failed to communicate with browser extension: %w", err)
+		return nil, fmt.Errorf("failed to communicate with browser extension: %w", err)
 	}
 
-	if screenshot, ok := result.(string); ok {
-		return screenshot, nil
+	// Extension replies with {"tab": id, "window": id, "data": "data:image/png;base64,..."}
+	fields, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, errors.NewTransportError("unexpected response format for screenshot", nil)
 	}
 
-	return "", errors.NewTransportError("unexpected response format", nil)
+	screenshot := &Screenshot{}
+	screenshot.Data, _ = fields["data"].(string)
+	if tabID, ok := fields["tab"].(float64); ok {
+		screenshot.TabID = int(tabID)
+	}
+	if windowID, ok := fields["window"].(float64); ok {
+		screenshot.WindowID = int(windowID)
+	}
+	if screenshot.Data == "" {
+		return nil, errors.NewTransportError("screenshot contained no image data", nil)
+	}
+
+	return screenshot, nil
 }
 
-// GetWords extracts words from tabs
+// GetWords extracts the unique words matching matchRegex from a tab, or from
+// the active tab of every window when tabID is nil.
 func (r *BrowserAPI) GetWords(tabID *int, matchRegex, joinWith string) ([]string, error) {
 	args := map[string]interface{}{
-		"matchRegex": matchRegex,
-		"joinWith":   joinWith,
+		"match_regex": jsRegex(matchRegex),
+		"join_with":   jsString(joinWith),
 	}
 	if tabID != nil {
-		args["tabId"] = *tabID
+		args["tab_id"] = *tabID
 	}
 
 	cmd := NewCommand(CmdGetWords, args)
@@ -285,8 +349,8 @@ func (r *BrowserAPI) GetWords(tabID *int, matchRegex, joinWith string) ([]string
 // The delimiter regex splits the text and replaceWith joins it back.
 func (r *BrowserAPI) GetText(delimiterRegex, replaceWith string) ([]string, error) {
 	cmd := NewCommand(CmdGetText, map[string]interface{}{
-		"delimiterRegex": delimiterRegex,
-		"replaceWith":    replaceWith,
+		"delimiter_regex": jsRegex(delimiterRegex),
+		"replace_with":    jsString(replaceWith),
 	})
 
 	result, err := r.sendCommand(cmd)
@@ -300,8 +364,8 @@ func (r *BrowserAPI) GetText(delimiterRegex, replaceWith string) ([]string, erro
 // GetHTML extracts HTML from tabs
 func (r *BrowserAPI) GetHTML(delimiterRegex, replaceWith string) ([]string, error) {
 	cmd := NewCommand(CmdGetHTML, map[string]interface{}{
-		"delimiterRegex": delimiterRegex,
-		"replaceWith":    replaceWith,
+		"delimiter_regex": jsRegex(delimiterRegex),
+		"replace_with":    jsString(replaceWith),
 	})
 
 	result, err := r.sendCommand(cmd)
@@ -362,4 +426,16 @@ func (r *BrowserAPI) parseStringArray(result interface{}, operation string) ([]s
 	}
 
 	return nil, errors.NewTransportError(fmt.Sprintf("unexpected response format for %s", operation), nil)
-}
\ No newline at end of file
+}
+
+// jsRegex renders a pattern as a global JavaScript regex literal, since the
+// extension splices it verbatim into the script it runs in the page.
+func jsRegex(pattern string) string {
+	return "/" + strings.ReplaceAll(pattern, "/", `\/`) + "/g"
+}
+
+// jsString renders s as a JavaScript string literal
+func jsString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
@@ -1,11 +1,14 @@
 package mediator
 
 import (
+	"encoding/base64"
 	"fmt"
 	"strconv"
 	"strings"
 
+	godbus "github.com/godbus/dbus/v5"
 	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/pkg/types"
 )
 
 // DBusHandler adapts BrowserAPI to the dbus.BrowserHandler interface
@@ -24,7 +27,11 @@ func (h *DBusHandler) ListTabs() ([]dbus.TabInfo, error) {
 		return nil, err
 	}
 
-	// Parse TSV format and convert to D-Bus format
+	return parseTabLines(tabLines), nil
+}
+
+// parseTabLines converts extension TSV lines into D-Bus tab info
+func parseTabLines(tabLines []string) []dbus.TabInfo {
 	var dbusTabsInfo []dbus.TabInfo
 	for _, line := range tabLines {
 		// TSV format: ID\tTitle\tURL\tIndex\tActive\tPinned
@@ -47,19 +54,27 @@ func (h *DBusHandler) ListTabs() ([]dbus.TabInfo, error) {
 		})
 	}
 
-	return dbusTabsInfo, nil
+	return dbusTabsInfo
 }
 
-func (h *DBusHandler) ActivateTab(tabID string) error {
-	// Extract numeric tab ID from format like "c.1.123"
+// parseNumericTabID extracts the browser's numeric tab ID from "c.1.123"
+func parseNumericTabID(tabID string) (int, error) {
 	parts := strings.Split(tabID, ".")
 	if len(parts) != 3 {
-		return fmt.Errorf("invalid tab ID format: %s", tabID)
+		return 0, fmt.Errorf("invalid tab ID format: %s", tabID)
 	}
 
 	tabIDNum, err := strconv.Atoi(parts[2])
 	if err != nil {
-		return fmt.Errorf("invalid tab ID: %s", parts[2])
+		return 0, fmt.Errorf("invalid tab ID: %s", parts[2])
+	}
+	return tabIDNum, nil
+}
+
+func (h *DBusHandler) ActivateTab(tabID string) error {
+	tabIDNum, err := parseNumericTabID(tabID)
+	if err != nil {
+		return err
 	}
 
 	// ActivateTab takes (tabID int, focused bool)
@@ -74,7 +89,7 @@ func (h *DBusHandler) CloseTab(tabID string) error {
 
 func (h *DBusHandler) OpenTab(url string) (string, error) {
 	// Use OpenURLs to open a single URL
-	tabIDs, err := h.api.OpenURLs([]string{url}, nil)
+	tabIDs, err := h.api.OpenURLs([]string{url}, nil, nil)
 	if err != nil {
 		return "", err
 	}
@@ -84,4 +99,173 @@ func (h *DBusHandler) OpenTab(url string) (string, error) {
 	}
 
 	return "", fmt.Errorf("failed to open tab")
-}
\ No newline at end of file
+}
+
+func (h *DBusHandler) QueryTabs(queryJSON string) ([]dbus.TabInfo, error) {
+	// The extension expects the query object as base64-encoded JSON
+	tabLines, err := h.api.QueryTabs(base64.StdEncoding.EncodeToString([]byte(queryJSON)))
+	if err != nil {
+		return nil, err
+	}
+	return parseTabLines(tabLines), nil
+}
+
+func (h *DBusHandler) MoveTabs(moves []dbus.TabMove) error {
+	triplets := make([][3]int, 0, len(moves))
+	for _, move := range moves {
+		tabIDNum, err := parseNumericTabID(move.TabID)
+		if err != nil {
+			return err
+		}
+		triplets = append(triplets, [3]int{tabIDNum, int(move.WindowID), int(move.Index)})
+	}
+
+	_, err := h.api.MoveTabs(triplets)
+	return err
+}
+
+func (h *DBusHandler) UpdateTabs(updates []dbus.TabUpdate) ([]string, error) {
+	apiUpdates := make([]map[string]interface{}, 0, len(updates))
+	for _, update := range updates {
+		tabIDNum, err := parseNumericTabID(update.TabID)
+		if err != nil {
+			return nil, err
+		}
+
+		properties := make(map[string]interface{}, len(update.Properties))
+		for key, value := range update.Properties {
+			properties[key] = value.Value()
+		}
+
+		apiUpdates = append(apiUpdates, map[string]interface{}{
+			"tab_id":     tabIDNum,
+			"properties": properties,
+		})
+	}
+
+	return h.api.UpdateTabs(apiUpdates)
+}
+
+func (h *DBusHandler) OpenURLs(urls []string, windowID int32, options map[string]godbus.Variant) ([]string, error) {
+	// Negative window IDs mean "current window"; 0 asks for a new window
+	var window *int
+	if windowID >= 0 {
+		id := int(windowID)
+		window = &id
+	}
+
+	// Only pass on the options the extension understands
+	openOptions := make(map[string]interface{})
+	for _, key := range []string{"active", "pinned"} {
+		if value, ok := options[key]; ok {
+			flag, ok := value.Value().(bool)
+			if !ok {
+				return nil, fmt.Errorf("option %s must be a boolean", key)
+			}
+			openOptions[key] = flag
+		}
+	}
+
+	return h.api.OpenURLs(urls, window, openOptions)
+}
+
+func (h *DBusHandler) NewTab(url string) (string, error) {
+	return h.api.NewTab(url)
+}
+
+func (h *DBusHandler) GetActiveTabs() ([]string, error) {
+	// Extension replies with a comma-separated list of tab IDs
+	joined, err := h.api.GetActiveTabs()
+	if err != nil {
+		return nil, err
+	}
+	if joined == "" {
+		return []string{}, nil
+	}
+	return strings.Split(joined, ","), nil
+}
+
+func (h *DBusHandler) GetScreenshot(windowID int32) (dbus.Screenshot, error) {
+	// Negative window IDs mean "last focused window"
+	var window *int
+	if windowID >= 0 {
+		id := int(windowID)
+		window = &id
+	}
+
+	screenshot, err := h.api.GetScreenshot(window)
+	if err != nil {
+		return dbus.Screenshot{}, err
+	}
+	return dbus.Screenshot{
+		Data:     screenshot.Data,
+		TabID:    int32(screenshot.TabID),
+		WindowID: int32(screenshot.WindowID),
+	}, nil
+}
+
+func (h *DBusHandler) GetWords(tabID, matchRegex, joinWith string) ([]string, error) {
+	if matchRegex == "" {
+		matchRegex = types.DefaultGetWordsMatchRegex
+	}
+	if joinWith == "" {
+		joinWith = types.DefaultGetWordsJoinWith
+	}
+
+	// An empty tab ID means the active tab of every window
+	var tab *int
+	if tabID != "" {
+		tabIDNum, err := parseNumericTabID(tabID)
+		if err != nil {
+			return nil, err
+		}
+		tab = &tabIDNum
+	}
+
+	return h.api.GetWords(tab, matchRegex, joinWith)
+}
+
+func (h *DBusHandler) GetText(delimiterRegex, replaceWith string) ([]dbus.TabContent, error) {
+	if delimiterRegex == "" {
+		delimiterRegex = types.DefaultGetTextDelimiterRegex
+		replaceWith = types.DefaultGetTextReplaceWith
+	}
+
+	lines, err := h.api.GetText(delimiterRegex, replaceWith)
+	if err != nil {
+		return nil, err
+	}
+	return parseContentLines(lines), nil
+}
+
+func (h *DBusHandler) GetHTML(delimiterRegex, replaceWith string) ([]dbus.TabContent, error) {
+	if delimiterRegex == "" {
+		delimiterRegex = types.DefaultGetHTMLDelimiterRegex
+		replaceWith = types.DefaultGetHTMLReplaceWith
+	}
+
+	lines, err := h.api.GetHTML(delimiterRegex, replaceWith)
+	if err != nil {
+		return nil, err
+	}
+	return parseContentLines(lines), nil
+}
+
+// parseContentLines converts extension "ID\tTitle\tURL\tContent" lines.
+// The content is everything after the third tab, so it may contain tabs itself.
+func parseContentLines(lines []string) []dbus.TabContent {
+	content := make([]dbus.TabContent, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.SplitN(line, "\t", 4)
+		if len(fields) < 4 {
+			continue // Skip malformed lines
+		}
+		content = append(content, dbus.TabContent{
+			ID:      fields[0],
+			Title:   fields[1],
+			URL:     fields[2],
+			Content: fields[3],
+		})
+	}
+	return content
+}
@@ -0,0 +1,192 @@
+package mediator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tabctl/tabctl/internal/dbus"
+)
+
+// Identity names the browser instance a mediator serves
+type Identity struct {
+	Browser string // product name: Firefox, Zen, Chrome, Brave, ...
+	Profile string // profile name, empty for the default profile
+}
+
+// Name returns the mediator name used on D-Bus: "Brave" or "Brave:work"
+func (id Identity) Name() string {
+	if id.Profile == "" {
+		return id.Browser
+	}
+	return id.Browser + ":" + id.Profile
+}
+
+// UnknownBrowser is used when no detection method identifies the browser
+const UnknownBrowser = "Unknown"
+
+// knownBrowsers maps executable names and extension answers (lowercase) to
+// browser names
+var knownBrowsers = map[string]string{
+	"firefox":          "Firefox",
+	"firefox-bin":      "Firefox",
+	"firefox-esr":      "Firefox",
+	"zen":              "Zen",
+	"zen-bin":          "Zen",
+	"zen-browser":      "Zen",
+	"librewolf":        "LibreWolf",
+	"waterfox":         "Waterfox",
+	"floorp":           "Floorp",
+	"chrome":           "Chrome",
+	"google-chrome":    "Chrome",
+	"chromium":         "Chromium",
+	"chromium-browser": "Chromium",
+	"brave":            "Brave",
+	"brave-browser":    "Brave",
+	"msedge":           "Edge",
+	"edge":             "Edge",
+	"opera":            "Opera",
+	"vivaldi":          "Vivaldi",
+	"vivaldi-bin":      "Vivaldi",
+}
+
+// manifestBrowsers maps native messaging manifest directories to browsers.
+// Only Firefox-based browsers pass the manifest path to the host.
+var manifestBrowsers = []struct {
+	dir     string
+	browser string
+}{
+	{"/.mozilla/", "Firefox"},
+	{"/.zen/", "Zen"},
+	{"/.librewolf/", "LibreWolf"},
+	{"/.waterfox/", "Waterfox"},
+	{"/.floorp/", "Floorp"},
+}
+
+// maxAncestors bounds the walk up the process tree; browsers may start the
+// host through a shell or a sandbox helper
+const maxAncestors = 4
+
+// DetectIdentity identifies the browser that started this process from the
+// process tree and the native messaging arguments. The extension's own
+// answer (see BrowserAPI.GetBrowser) is more precise and should take
+// precedence when available.
+func DetectIdentity(args []string) Identity {
+	if id, ok := identityFromProcess(os.Getppid()); ok {
+		return id
+	}
+	return Identity{Browser: browserFromArgs(args)}
+}
+
+// ResolveBrowser maps a browser name reported by the extension to a known
+// browser name, or returns "" for generic answers such as "chrome/chromium"
+func ResolveBrowser(reported string) string {
+	return knownBrowsers[strings.ToLower(strings.TrimSpace(reported))]
+}
+
+// identityFromProcess walks up from pid to the first known browser process
+func identityFromProcess(pid int) (Identity, bool) {
+	for i := 0; i < maxAncestors && pid > 1; i++ {
+		exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+		if err == nil {
+			if browser, ok := knownBrowsers[strings.ToLower(filepath.Base(exe))]; ok {
+				return Identity{Browser: browser, Profile: profileFromCmdline(pid)}, true
+			}
+		}
+
+		pid, err = parentPID(pid)
+		if err != nil {
+			break
+		}
+	}
+	return Identity{}, false
+}
+
+// parentPID reads the parent process ID from /proc/<pid>/stat
+func parentPID(pid int) (int, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name is in parentheses and may contain spaces
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	return strconv.Atoi(fields[1])
+}
+
+// profileFromCmdline extracts a non-default profile from the browser's
+// command line
+func profileFromCmdline(pid int) string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return ""
+	}
+	return profileFromArgs(strings.Split(strings.TrimRight(string(data), "\x00"), "\x00"))
+}
+
+// profileFromArgs extracts a non-default profile from a browser's arguments,
+// args[0] being the executable: --user-data-dir and --profile-directory for
+// Chromium-based browsers, -P and -profile for Firefox-based ones
+func profileFromArgs(args []string) string {
+	var parts []string
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		next := ""
+		if i+1 < len(args) {
+			next = args[i+1]
+		}
+
+		switch {
+		case strings.HasPrefix(arg, "--user-data-dir="):
+			parts = append(parts, filepath.Base(strings.TrimPrefix(arg, "--user-data-dir=")))
+		case strings.HasPrefix(arg, "--profile-directory="):
+			if dir := strings.TrimPrefix(arg, "--profile-directory="); dir != "Default" {
+				parts = append(parts, dir)
+			}
+		case arg == "-P" || arg == "-p" || arg == "--P":
+			if next != "" && !strings.HasPrefix(next, "-") {
+				parts = append(parts, next)
+				i++
+			}
+		case arg == "-profile" || arg == "--profile":
+			if next != "" {
+				parts = append(parts, filepath.Base(next))
+				i++
+			}
+		}
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return dbus.ProfileElement(strings.Join(parts, "_"))
+}
+
+// browserFromArgs guesses the browser from the native messaging arguments:
+// Firefox-based browsers pass the manifest path, Chromium-based ones the
+// extension origin
+func browserFromArgs(args []string) string {
+	for _, arg := range args {
+		for _, m := range manifestBrowsers {
+			if strings.Contains(arg, m.dir) {
+				return m.browser
+			}
+		}
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "chrome-extension://") {
+			return "Chromium"
+		}
+	}
+	return UnknownBrowser
+}
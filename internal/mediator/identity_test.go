@@ -0,0 +1,78 @@
+package mediator
+
+import "testing"
+
+func TestProfileFromArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no profile", []string{"/usr/bin/firefox"}, ""},
+		{"firefox -P", []string{"firefox", "-P", "work"}, "work"},
+		{"firefox -p", []string{"firefox", "-p", "work", "--new-window"}, "work"},
+		{"firefox -P with an option next", []string{"firefox", "-P", "--no-remote"}, ""},
+		{"firefox -profile", []string{"firefox", "-profile", "/home/me/.mozilla/firefox/abcd.work"}, "abcd_work"},
+		{"firefox --profile", []string{"firefox", "--profile", "/tmp/p"}, "p"},
+		{"chrome user data dir", []string{"chrome", "--user-data-dir=/home/me/.config/chrome-work"}, "chrome_work"},
+		{"chrome profile directory", []string{"chrome", "--profile-directory=Profile 1"}, "Profile_1"},
+		{"chrome default profile directory", []string{"chrome", "--profile-directory=Default"}, ""},
+		{"chrome both", []string{"chrome", "--user-data-dir=/srv/work", "--profile-directory=Profile 2"}, "work_Profile_2"},
+		{"looks like an instance", []string{"chrome", "--user-data-dir=/srv/i2"}, "_i2"},
+		{"starts with a digit", []string{"firefox", "-P", "2024"}, "_2024"},
+		{"executable is not an argument", []string{"-P", "work"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := profileFromArgs(tt.args); got != tt.want {
+				t.Errorf("profileFromArgs(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrowserFromArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"/home/me/.mozilla/native-messaging-hosts/tabctl.json", "tabctl@example"}, "Firefox"},
+		{[]string{"/home/me/.zen/native-messaging-hosts/tabctl.json"}, "Zen"},
+		{[]string{"/home/me/.librewolf/native-messaging-hosts/tabctl.json"}, "LibreWolf"},
+		{[]string{"chrome-extension://abcdefghijklmnop/"}, "Chromium"},
+		{[]string{"--parent-window=0", "chrome-extension://abcdefghijklmnop/"}, "Chromium"},
+		{nil, UnknownBrowser},
+		{[]string{"/etc/tabctl.json"}, UnknownBrowser},
+	}
+	for _, tt := range tests {
+		if got := browserFromArgs(tt.args); got != tt.want {
+			t.Errorf("browserFromArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestResolveBrowser(t *testing.T) {
+	tests := []struct{ reported, want string }{
+		{"Firefox", "Firefox"},
+		{" brave ", "Brave"},
+		{"google-chrome", "Chrome"},
+		{"msedge", "Edge"},
+		{"chrome/chromium", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ResolveBrowser(tt.reported); got != tt.want {
+			t.Errorf("ResolveBrowser(%q) = %q, want %q", tt.reported, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityName(t *testing.T) {
+	if got := (Identity{Browser: "Brave"}).Name(); got != "Brave" {
+		t.Errorf("Name = %s, want Brave", got)
+	}
+	if got := (Identity{Browser: "Brave", Profile: "work"}).Name(); got != "Brave:work" {
+		t.Errorf("Name = %s, want Brave:work", got)
+	}
+}
@@ -15,30 +15,42 @@ type Mediator struct {
 }
 
 // NewMediator creates a new mediator with automatic disconnection detection.
-func NewMediator(browser string) (*Mediator, error) {
+// The browser name reported by the extension takes precedence over the
+// detected one; the profile always comes from the detected identity.
+func NewMediator(identity Identity) (*Mediator, error) {
 	// Create transport with automatic browser disconnection detection
 	transport := NewStdTransport(os.Stdin, os.Stdout)
 
-	// Create browser API handler
-	browserAPI := NewBrowserAPI(transport, browser)
+	// Create browser API handler and ask the extension which browser it runs in
+	browserAPI := NewBrowserAPI(transport, "")
+	if browser := ResolveBrowser(browserAPI.GetBrowser()); browser != "" {
+		identity.Browser = browser
+	}
+	browserAPI.browser = identity.Name()
 
 	// Create D-Bus handler adapter
 	dbusHandler := NewDBusHandler(browserAPI)
 
 	// Create D-Bus server
-	dbusServer, err := dbus.NewServer(browser, dbusHandler)
+	dbusServer, err := dbus.NewServer(identity.Name(), dbusHandler)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Mediator{
-		browser:    browser,
+		browser:    identity.Name(),
 		browserAPI: browserAPI,
 		dbusServer: dbusServer,
 		transport:  transport,
 	}, nil
 }
 
+// Name returns the mediator name. Once running, it includes the instance
+// number if another mediator of the same browser profile was already on D-Bus.
+func (m *Mediator) Name() string {
+	return m.browser
+}
+
 // Run starts the D-Bus server and keeps running until the browser disconnects.
 func (m *Mediator) Run() error {
 	// Start D-Bus server
@@ -46,6 +58,13 @@ func (m *Mediator) Run() error {
 		return err
 	}
 
+	// Another instance may hold the name, the server then numbers this one
+	m.browser = m.dbusServer.Name()
+	m.browserAPI.browser = m.browser
+
+	// Re-publish browser events as D-Bus signals
+	go m.forwardEvents()
+
 	// Monitor for browser disconnection (non-polling, immediate detection)
 	go func() {
 		<-m.transport.GetErrorChannel()
@@ -57,6 +76,38 @@ func (m *Mediator) Run() error {
 	select {}
 }
 
+// forwardEvents emits a D-Bus signal for every event pushed by the browser
+// until the transport closes.
+func (m *Mediator) forwardEvents() {
+	for event := range m.transport.Events() {
+		tab := dbus.TabInfo{
+			ID:     event.TabID,
+			Title:  event.Title,
+			URL:    event.URL,
+			Index:  int32(event.Index),
+			Active: event.Active,
+			Pinned: event.Pinned,
+		}
+		windowID := int32(event.WindowID)
+
+		// Emission only fails once the bus connection is gone
+		switch event.Event {
+		case EventTabCreated:
+			m.dbusServer.EmitTabCreated(tab)
+		case EventTabRemoved:
+			m.dbusServer.EmitTabRemoved(event.TabID, windowID)
+		case EventTabUpdated:
+			m.dbusServer.EmitTabUpdated(tab)
+		case EventTabActivated:
+			m.dbusServer.EmitTabActivated(event.TabID, windowID)
+		case EventTabMoved:
+			m.dbusServer.EmitTabMoved(event.TabID, windowID, int32(event.FromIndex), int32(event.ToIndex))
+		case EventWindowFocused:
+			m.dbusServer.EmitWindowFocused(windowID)
+		}
+	}
+}
+
 // Shutdown gracefully shuts down the mediator.
 func (m *Mediator) Shutdown() error {
 	if m.dbusServer != nil {
@@ -2,18 +2,53 @@ package mediator
 
 // Message types for browser extension communication
 
-// Command represents a command to send to the browser
+// Command represents a command to send to the browser.
+// The ID is assigned by the transport and echoed back by the extension.
 type Command struct {
+	ID      uint64                 `json:"id,omitempty"`
 	Command string                 `json:"name"`
 	Args    map[string]interface{} `json:"args,omitempty"`
 }
 
 // Response represents a response from the browser
 type Response struct {
+	ID     uint64      `json:"id,omitempty"`
 	Result interface{} `json:"result,omitempty"`
 	Error  string      `json:"error,omitempty"`
 }
 
+// Screenshot represents a capture of the visible area of a tab
+type Screenshot struct {
+	Data     string // data URL, e.g. "data:image/png;base64,..."
+	TabID    int
+	WindowID int
+}
+
+// Event represents an unsolicited tab or window event pushed by the browser.
+// Which fields are populated depends on the event name.
+type Event struct {
+	Event     string `json:"event"`
+	TabID     string `json:"tab_id,omitempty"`
+	WindowID  int    `json:"window_id"`
+	Index     int    `json:"index"`
+	FromIndex int    `json:"from_index"`
+	ToIndex   int    `json:"to_index"`
+	Title     string `json:"title,omitempty"`
+	URL       string `json:"url,omitempty"`
+	Active    bool   `json:"active"`
+	Pinned    bool   `json:"pinned"`
+}
+
+// Event names pushed by the extensions
+const (
+	EventTabCreated    = "tab_created"
+	EventTabRemoved    = "tab_removed"
+	EventTabUpdated    = "tab_updated"
+	EventTabActivated  = "tab_activated"
+	EventTabMoved      = "tab_moved"
+	EventWindowFocused = "window_focused"
+)
+
 // TabInfo represents information about a tab
 type TabInfo struct {
 	ID       int    `json:"id"`
@@ -75,4 +110,4 @@ type ValidationError struct {
 
 func (e *ValidationError) Error() string {
 	return e.Message
-}
\ No newline at end of file
+}
@@ -2,24 +2,37 @@ package mediator
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/tabctl/tabctl/internal/errors"
 )
 
-// StdTransport implements Transport using channels for non-blocking EOF detection
+// StdTransport implements Transport using channels for non-blocking EOF detection.
+// Responses carrying a command ID are routed to the caller waiting in Request,
+// browser events are delivered through Events, and everything else through Recv.
 type StdTransport struct {
-	input       io.Reader
-	output      io.Writer
-	msgChan     chan map[string]interface{}
-	errChan     chan error
-	closeChan   chan struct{}
-	closeOnce   sync.Once
+	input     io.Reader
+	output    io.Writer
+	msgChan   chan map[string]interface{}
+	eventChan chan Event
+	errChan   chan error
+	closeChan chan struct{}
+	closeOnce sync.Once
+	done      chan struct{} // closed when readLoop exits
+	err       error         // reason readLoop exited, valid after done is closed
+
+	writeMu   sync.Mutex // serializes framed writes to output
+	pendingMu sync.Mutex
+	pending   map[uint64]chan map[string]interface{}
+	nextID    uint64
 }
 
 // NewStdTransport creates a new transport with automatic EOF detection
@@ -28,8 +41,11 @@ func NewStdTransport(input io.Reader, output io.Writer) *StdTransport {
 		input:     input,
 		output:    output,
 		msgChan:   make(chan map[string]interface{}, 10), // Buffer for smoother operation
+		eventChan: make(chan Event, 64),
 		errChan:   make(chan error, 1),
 		closeChan: make(chan struct{}),
+		done:      make(chan struct{}),
+		pending:   make(map[uint64]chan map[string]interface{}),
 	}
 
 	// Start the stdin reader goroutine
@@ -46,7 +62,9 @@ func NewDefaultTransport() *StdTransport {
 // readLoop continuously reads from stdin in a goroutine
 func (t *StdTransport) readLoop() {
 	defer func() {
+		close(t.done)
 		close(t.msgChan)
+		close(t.eventChan)
 		close(t.errChan)
 	}()
 
@@ -65,38 +83,38 @@ func (t *StdTransport) readLoop() {
 		if err != nil {
 			if err == io.EOF || n == 0 {
 				// Browser disconnected cleanly
-				t.errChan <- errors.NewTransportError("connection closed", io.EOF)
+				t.fail(errors.NewTransportError("connection closed", io.EOF))
 				return
 			}
 			// Unexpected error
-			t.errChan <- errors.NewTransportError("failed to read message length", err)
+			t.fail(errors.NewTransportError("failed to read message length", err))
 			return
 		}
 
 		// Parse message length
 		var length uint32
 		if err := binary.Read(bytes.NewReader(lengthBytes), binary.LittleEndian, &length); err != nil {
-			t.errChan <- errors.NewTransportError("failed to parse message length", err)
+			t.fail(errors.NewTransportError("failed to parse message length", err))
 			return
 		}
 
 		// Validate message length
 		if length > MaxMessageSize {
-			t.errChan <- errors.NewTransportError(fmt.Sprintf("message too large: %d bytes", length), nil)
+			t.fail(errors.NewTransportError(fmt.Sprintf("message too large: %d bytes", length), nil))
 			return
 		}
 
 		// Read message content
 		messageData := make([]byte, length)
 		if _, err := io.ReadFull(t.input, messageData); err != nil {
-			t.errChan <- errors.NewTransportError("failed to read message content", err)
+			t.fail(errors.NewTransportError("failed to read message content", err))
 			return
 		}
 
 		// Decode JSON
 		var message map[string]interface{}
 		if err := json.Unmarshal(messageData, &message); err != nil {
-			t.errChan <- errors.NewTransportError("failed to unmarshal message", err)
+			t.fail(errors.NewTransportError("failed to unmarshal message", err))
 			return
 		}
 
@@ -105,13 +123,73 @@ func (t *StdTransport) readLoop() {
 			continue // Don't forward ping/health check messages
 		}
 
-		// Send message to channel
-		select {
-		case t.msgChan <- message:
-			// Message sent successfully
-		case <-t.closeChan:
-			return
+		// Route browser events away from command replies
+		if msgType, _ := message["type"].(string); msgType == MsgTypeEvent {
+			t.dispatchEvent(messageData)
+			continue
 		}
+
+		// Route command responses to the caller waiting for them
+		if handled := t.dispatchResponse(message); handled {
+			continue
+		}
+
+		t.dispatchUncorrelated(message)
+	}
+}
+
+// fail records the reason the read loop stopped and reports it on errChan
+func (t *StdTransport) fail(err error) {
+	t.err = err
+	t.errChan <- err
+}
+
+// dispatchResponse delivers a message carrying a command ID to the matching
+// pending request. Responses nobody is waiting for (e.g. replies that arrive
+// after the caller timed out) are dropped.
+func (t *StdTransport) dispatchResponse(message map[string]interface{}) bool {
+	rawID, ok := message["id"].(float64)
+	if !ok {
+		return false
+	}
+	id := uint64(rawID)
+
+	t.pendingMu.Lock()
+	ch, ok := t.pending[id]
+	if ok {
+		delete(t.pending, id)
+	}
+	t.pendingMu.Unlock()
+
+	if ok {
+		// Buffered with room for exactly one response, never blocks
+		ch <- message
+	}
+	return true
+}
+
+// dispatchEvent decodes an event and queues it for Events. Events are dropped
+// rather than stalling the read loop when nobody keeps up with them.
+func (t *StdTransport) dispatchEvent(messageData []byte) {
+	var event Event
+	if err := json.Unmarshal(messageData, &event); err != nil || event.Event == "" {
+		return
+	}
+
+	select {
+	case t.eventChan <- event:
+	default:
+	}
+}
+
+// dispatchUncorrelated queues a message that is neither a reply nor an event
+// for Recv. Nothing in the mediator reads them, so they are dropped rather
+// than stalling the read loop once the buffer is full.
+func (t *StdTransport) dispatchUncorrelated(message map[string]interface{}) {
+	select {
+	case t.msgChan <- message:
+	default:
+		log.Printf("Dropped a message from the browser without a command ID: %v", message)
 	}
 }
 
@@ -147,6 +225,9 @@ func (t *StdTransport) Send(message interface{}) error {
 		return errors.NewTransportError("failed to marshal message", err)
 	}
 
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
 	// Write length header (4 bytes, little-endian)
 	length := uint32(len(jsonData))
 	if err := binary.Write(t.output, binary.LittleEndian, length); err != nil {
@@ -168,6 +249,49 @@ func (t *StdTransport) Send(message interface{}) error {
 	return nil
 }
 
+// Request sends a command to the browser and waits for the response that
+// echoes its ID. It is safe to call from multiple goroutines at once.
+// If ctx is done first, ctx.Err() is returned and a late response is dropped.
+func (t *StdTransport) Request(ctx context.Context, cmd *Command) (map[string]interface{}, error) {
+	id := atomic.AddUint64(&t.nextID, 1)
+	cmd.ID = id
+
+	ch := make(chan map[string]interface{}, 1)
+	t.pendingMu.Lock()
+	t.pending[id] = ch
+	t.pendingMu.Unlock()
+
+	defer func() {
+		t.pendingMu.Lock()
+		delete(t.pending, id)
+		t.pendingMu.Unlock()
+	}()
+
+	if err := t.Send(cmd); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-ch:
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-t.closeChan:
+		return nil, errors.NewTransportError("transport closed", nil)
+	case <-t.done:
+		// The response may have been dispatched just before the loop exited
+		select {
+		case response := <-ch:
+			return response, nil
+		default:
+		}
+		if t.err != nil {
+			return nil, t.err
+		}
+		return nil, errors.NewTransportError("transport closed", nil)
+	}
+}
+
 // Recv receives a message from the browser (non-blocking via channel)
 func (t *StdTransport) Recv() (map[string]interface{}, error) {
 	select {
@@ -181,6 +305,12 @@ func (t *StdTransport) Recv() (map[string]interface{}, error) {
 	}
 }
 
+// Events returns the channel of events pushed by the browser.
+// It is closed when the browser disconnects.
+func (t *StdTransport) Events() <-chan Event {
+	return t.eventChan
+}
+
 // GetErrorChannel returns the error channel for monitoring disconnection
 func (t *StdTransport) GetErrorChannel() <-chan error {
 	return t.errChan
@@ -192,4 +322,4 @@ func (t *StdTransport) Close() error {
 		close(t.closeChan)
 	})
 	return nil
-}
\ No newline at end of file
+}
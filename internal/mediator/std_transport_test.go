@@ -0,0 +1,278 @@
+package mediator
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tabctl/tabctl/internal/errors"
+)
+
+// fakeExtension speaks the native messaging protocol on the browser side of
+// an in-memory pipe pair.
+type fakeExtension struct {
+	in  io.Reader // commands written by the transport
+	out io.Writer // responses read by the transport
+	mu  sync.Mutex
+}
+
+// newTransportPair wires a StdTransport to a fake extension via io.Pipe.
+func newTransportPair(t *testing.T) (*StdTransport, *fakeExtension) {
+	t.Helper()
+
+	toExt, fromMediator := io.Pipe()
+	toMediator, fromExt := io.Pipe()
+
+	transport := NewStdTransport(toMediator, fromMediator)
+	ext := &fakeExtension{in: toExt, out: fromExt}
+
+	t.Cleanup(func() {
+		transport.Close()
+		fromExt.Close()
+		toExt.Close()
+	})
+
+	return transport, ext
+}
+
+func (e *fakeExtension) readCommand() (map[string]interface{}, error) {
+	var length uint32
+	if err := binary.Read(e.in, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(e.in, data); err != nil {
+		return nil, err
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (e *fakeExtension) write(msg interface{}) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := binary.Write(e.out, binary.LittleEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = e.out.Write(data)
+	return err
+}
+
+func TestStdTransportRequestOutOfOrder(t *testing.T) {
+	transport, ext := newTransportPair(t)
+
+	const n = 8
+
+	// Collect every command before answering, then reply in reverse order
+	go func() {
+		var cmds []map[string]interface{}
+		for i := 0; i < n; i++ {
+			cmd, err := ext.readCommand()
+			if err != nil {
+				return
+			}
+			cmds = append(cmds, cmd)
+		}
+		for i := len(cmds) - 1; i >= 0; i-- {
+			args := cmds[i]["args"].(map[string]interface{})
+			ext.write(map[string]interface{}{
+				"id":     cmds[i]["id"],
+				"result": args["n"],
+			})
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := transport.Request(context.Background(), NewCommand(CmdListTabs, map[string]interface{}{"n": i}))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if got, _ := resp["result"].(float64); int(got) != i {
+				errs <- fmt.Errorf("request %d got response for %v", i, resp["result"])
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for responses")
+	}
+
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestStdTransportUncorrelatedMessagesGoToRecv(t *testing.T) {
+	transport, ext := newTransportPair(t)
+
+	go ext.write(map[string]interface{}{"result": "unsolicited"})
+
+	msg, err := transport.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if msg["result"] != "unsolicited" {
+		t.Fatalf("unexpected message: %v", msg)
+	}
+}
+
+func TestStdTransportUncorrelatedMessagesDoNotBlock(t *testing.T) {
+	transport, ext := newTransportPair(t)
+
+	// Many more stray messages than msgChan holds, with nobody calling Recv,
+	// then the reply to a command
+	go func() {
+		for i := 0; i < 50; i++ {
+			ext.write(map[string]interface{}{"result": i})
+		}
+		cmd, err := ext.readCommand()
+		if err != nil {
+			return
+		}
+		ext.write(map[string]interface{}{"id": cmd["id"], "result": "answered"})
+	}()
+
+	type reply struct {
+		resp map[string]interface{}
+		err  error
+	}
+	replies := make(chan reply, 1)
+	go func() {
+		resp, err := transport.Request(context.Background(), NewCommand(CmdListTabs, nil))
+		replies <- reply{resp, err}
+	}()
+
+	select {
+	case r := <-replies:
+		if r.err != nil {
+			t.Fatalf("Request after stray messages: %v", r.err)
+		}
+		if r.resp["result"] != "answered" {
+			t.Fatalf("unexpected response: %v", r.resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Request did not return: the read loop is stuck on stray messages")
+	}
+}
+
+func TestStdTransportRequestFailsOnDisconnect(t *testing.T) {
+	toExt, fromMediator := io.Pipe()
+	toMediator, fromExt := io.Pipe()
+	transport := NewStdTransport(toMediator, fromMediator)
+	ext := &fakeExtension{in: toExt, out: fromExt}
+	defer toExt.Close()
+
+	// Swallow the command, then disconnect without replying
+	go func() {
+		ext.readCommand()
+		fromExt.Close()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := transport.Request(context.Background(), NewCommand(CmdListTabs, nil))
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatal("expected error after disconnect")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Request did not return after disconnect")
+	}
+}
+
+func TestBrowserAPITimeoutDropsLateReply(t *testing.T) {
+	transport, ext := newTransportPair(t)
+	api := NewBrowserAPI(transport, "Test")
+	api.SetTimeout(CmdGetActiveTabs, 50*time.Millisecond)
+
+	// Answer the first command only after its caller gave up, then the second promptly
+	go func() {
+		first, err := ext.readCommand()
+		if err != nil {
+			return
+		}
+		second, err := ext.readCommand()
+		if err != nil {
+			return
+		}
+		ext.write(map[string]interface{}{"id": first["id"], "result": "late"})
+		ext.write(map[string]interface{}{"id": second["id"], "result": "on time"})
+	}()
+
+	_, err := api.GetActiveTabs()
+	var timeoutErr *errors.TimeoutError
+	if !stderrors.As(err, &timeoutErr) {
+		t.Fatalf("expected TimeoutError, got %v", err)
+	}
+
+	got, err := api.GetActiveTabs()
+	if err != nil {
+		t.Fatalf("second request: %v", err)
+	}
+	if got != "on time" {
+		t.Fatalf("second request got %q, late reply was not discarded", got)
+	}
+}
+
+func TestStdTransportRoutesEvents(t *testing.T) {
+	transport, ext := newTransportPair(t)
+
+	// An event arriving while a request is pending must not be taken as its reply
+	go func() {
+		cmd, err := ext.readCommand()
+		if err != nil {
+			return
+		}
+		ext.write(map[string]interface{}{"type": MsgTypeEvent, "event": EventTabActivated, "tab_id": "f.1.2", "window_id": 1})
+		ext.write(map[string]interface{}{"id": cmd["id"], "result": "OK"})
+	}()
+
+	resp, err := transport.Request(context.Background(), NewCommand(CmdActivateTab, nil))
+	if err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if resp["result"] != "OK" {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+
+	select {
+	case event := <-transport.Events():
+		if event.Event != EventTabActivated || event.TabID != "f.1.2" || event.WindowID != 1 {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+}
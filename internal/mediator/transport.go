@@ -2,6 +2,8 @@
 // It handles communication between the browser extension and the CLI tool.
 package mediator
 
+import "context"
+
 // Message type constants for the native messaging protocol
 const (
 	// MsgTypePing is sent by the browser to check if the host is alive
@@ -12,6 +14,8 @@ const (
 	MsgTypeHealthCheck = "health_check"
 	// MsgTypeHealthCheckResponse is the response to a health check
 	MsgTypeHealthCheckResponse = "health_check_response"
+	// MsgTypeEvent is an unsolicited tab/window event pushed by the browser
+	MsgTypeEvent = "event"
 
 	// MaxMessageSize is the maximum allowed message size (10MB)
 	MaxMessageSize = 10 * 1024 * 1024
@@ -26,8 +30,9 @@ type Transport interface {
 	// Recv receives and decodes a message from the browser.
 	// It automatically handles ping/health check messages internally.
 	Recv() (map[string]interface{}, error)
+	// Request sends a command and waits for the response echoing its ID
+	// until ctx is done. Multiple requests may be in flight concurrently.
+	Request(ctx context.Context, cmd *Command) (map[string]interface{}, error)
 	// Close cleans up any resources (no-op for stdio)
 	Close() error
 }
-
-
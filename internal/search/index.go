@@ -0,0 +1,362 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tabctl/tabctl/internal/platform"
+	"github.com/tabctl/tabctl/internal/utils"
+	"github.com/tabctl/tabctl/pkg/api"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+const (
+	// IndexFileName is the name of the index file in the cache directory
+	IndexFileName = "search-index.json"
+
+	indexVersion = 1
+
+	// maxContentBytes caps the text kept per tab so huge pages don't bloat the index
+	maxContentBytes = 256 * 1024
+
+	// titleWeight counts title words this many times, so title matches rank higher
+	titleWeight = 3
+
+	// BM25 parameters
+	bm25K1 = 1.2
+	bm25B  = 0.75
+
+	snippetBefore = 60
+	snippetLength = 200
+)
+
+// Index is a full-text index over tab contents, ranked with BM25 and stored
+// as a JSON file. Only the tab contents are stored; the inverted index is
+// rebuilt in memory when the file is loaded.
+type Index struct {
+	path     string
+	updated  time.Time
+	docs     map[string]*document
+	postings map[string]map[string]int // term -> tab ID -> term frequency
+	totalLen int
+}
+
+var _ api.SearchAPI = (*Index)(nil)
+
+type document struct {
+	content types.TabContent
+	length  int
+}
+
+// indexFile is the on-disk representation of an Index
+type indexFile struct {
+	Version int                `json:"version"`
+	Updated time.Time          `json:"updated"`
+	Tabs    []types.TabContent `json:"tabs"`
+}
+
+// DefaultPath returns the location of the index in the tabctl cache directory
+func DefaultPath() (string, error) {
+	cacheDir, err := platform.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, IndexFileName), nil
+}
+
+// Open loads the index stored at path. A missing file yields an empty index
+// that is created on the first write.
+func Open(path string) (*Index, error) {
+	idx := &Index{
+		path:     path,
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string]int),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search index: %w", err)
+	}
+
+	var file indexFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse search index %s: %w", path, err)
+	}
+	if file.Version != indexVersion {
+		// Incompatible layout: start over, the next refresh rebuilds it
+		return idx, nil
+	}
+
+	idx.updated = file.Updated
+	for _, tab := range file.Tabs {
+		idx.add(tab)
+	}
+	return idx, nil
+}
+
+// Len returns the number of indexed tabs
+func (idx *Index) Len() int {
+	return len(idx.docs)
+}
+
+// Updated returns when the index was last written
+func (idx *Index) Updated() time.Time {
+	return idx.updated
+}
+
+// IndexTabs adds or replaces the given tabs. Indexed tabs of the same
+// browsers (tab ID prefixes) that are not in tabs are dropped, so indexing
+// one browser leaves the others untouched.
+func (idx *Index) IndexTabs(tabs []types.TabContent) error {
+	prefixes := make(map[string]bool)
+	for _, tab := range tabs {
+		prefixes[utils.GetTabPrefix(tab.TabID)] = true
+	}
+
+	for tabID := range idx.docs {
+		if prefixes[utils.GetTabPrefix(tabID)] {
+			idx.remove(tabID)
+		}
+	}
+	for _, tab := range tabs {
+		idx.add(tab)
+	}
+
+	return idx.save()
+}
+
+// UpdateIndex adds or replaces a single tab
+func (idx *Index) UpdateIndex(tabID string, content types.TabContent) error {
+	content.TabID = tabID
+	idx.remove(tabID)
+	idx.add(content)
+	return idx.save()
+}
+
+// Search returns the tabs matching every word of query, best match first.
+// Double-quoted parts of the query must appear verbatim (ignoring case).
+// Rank is the BM25 score: higher is better.
+func (idx *Index) Search(query string) ([]types.SearchResult, error) {
+	terms, phrases := parseQuery(query)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+
+	candidates := idx.match(terms)
+
+	results := make([]types.SearchResult, 0, len(candidates))
+	for _, tabID := range candidates {
+		doc := idx.docs[tabID]
+		if !containsPhrases(doc.content, phrases) {
+			continue
+		}
+		results = append(results, types.SearchResult{
+			TabID:   doc.content.TabID,
+			Title:   doc.content.Title,
+			URL:     doc.content.URL,
+			Snippet: snippet(doc.content.Content, terms),
+			Rank:    idx.score(tabID, terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].TabID < results[j].TabID
+	})
+
+	return results, nil
+}
+
+// match returns the IDs of the documents containing every term
+func (idx *Index) match(terms []string) []string {
+	var matched []string
+	for tabID := range idx.postings[terms[0]] {
+		all := true
+		for _, term := range terms[1:] {
+			if _, ok := idx.postings[term][tabID]; !ok {
+				all = false
+				break
+			}
+		}
+		if all {
+			matched = append(matched, tabID)
+		}
+	}
+	return matched
+}
+
+// score computes the BM25 score of a document for the query terms
+func (idx *Index) score(tabID string, terms []string) float64 {
+	n := float64(len(idx.docs))
+	avgLen := float64(idx.totalLen) / n
+	docLen := float64(idx.docs[tabID].length)
+
+	var score float64
+	for _, term := range terms {
+		postings := idx.postings[term]
+		tf := float64(postings[tabID])
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*docLen/avgLen))
+	}
+	return score
+}
+
+func (idx *Index) add(tab types.TabContent) {
+	if len(tab.Content) > maxContentBytes {
+		cut := maxContentBytes
+		for cut > 0 && !utf8.RuneStart(tab.Content[cut]) {
+			cut--
+		}
+		tab.Content = tab.Content[:cut]
+	}
+
+	frequencies := make(map[string]int)
+	length := 0
+	for _, term := range tokenize(tab.Title) {
+		frequencies[term] += titleWeight
+		length += titleWeight
+	}
+	for _, term := range append(tokenize(tab.URL), tokenize(tab.Content)...) {
+		frequencies[term]++
+		length++
+	}
+
+	for term, tf := range frequencies {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[string]int)
+		}
+		idx.postings[term][tab.TabID] = tf
+	}
+	idx.docs[tab.TabID] = &document{content: tab, length: length}
+	idx.totalLen += length
+}
+
+func (idx *Index) remove(tabID string) {
+	doc, ok := idx.docs[tabID]
+	if !ok {
+		return
+	}
+
+	for term, postings := range idx.postings {
+		delete(postings, tabID)
+		if len(postings) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLen -= doc.length
+	delete(idx.docs, tabID)
+}
+
+// save writes the index atomically so an interrupted write never corrupts it
+func (idx *Index) save() error {
+	tabs := make([]types.TabContent, 0, len(idx.docs))
+	for _, doc := range idx.docs {
+		tabs = append(tabs, doc.content)
+	}
+	sort.Slice(tabs, func(i, j int) bool { return tabs[i].TabID < tabs[j].TabID })
+
+	idx.updated = time.Now()
+	data, err := json.Marshal(indexFile{Version: indexVersion, Updated: idx.updated, Tabs: tabs})
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+
+	if err := platform.EnsureDir(filepath.Dir(idx.path)); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	if err := os.Rename(tmp, idx.path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write search index: %w", err)
+	}
+	return nil
+}
+
+// tokenize splits text into lowercase words
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// parseQuery returns the words of a query and its double-quoted phrases
+func parseQuery(query string) (terms, phrases []string) {
+	parts := strings.Split(query, `"`)
+	for i, part := range parts {
+		// Odd parts are inside quotes
+		if i%2 == 1 && strings.TrimSpace(part) != "" {
+			phrases = append(phrases, strings.ToLower(strings.Join(strings.Fields(part), " ")))
+		}
+		terms = append(terms, tokenize(part)...)
+	}
+	return terms, phrases
+}
+
+// containsPhrases reports whether the title or content contains every phrase
+func containsPhrases(tab types.TabContent, phrases []string) bool {
+	if len(phrases) == 0 {
+		return true
+	}
+	text := strings.ToLower(strings.Join(strings.Fields(tab.Title+" "+tab.Content), " "))
+	for _, phrase := range phrases {
+		if !strings.Contains(text, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// snippet returns the part of content around the first query term
+func snippet(content string, terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	pattern := regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+
+	start := 0
+	if loc := pattern.FindStringIndex(content); loc != nil {
+		start = loc[0] - snippetBefore
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + snippetLength
+	if end > len(content) {
+		end = len(content)
+	}
+
+	for start > 0 && !utf8.RuneStart(content[start]) {
+		start--
+	}
+	for end < len(content) && !utf8.RuneStart(content[end]) {
+		end++
+	}
+
+	text := strings.Join(strings.Fields(content[start:end]), " ")
+	if start > 0 {
+		text = "…" + text
+	}
+	if end < len(content) {
+		text += "…"
+	}
+	return text
+}
@@ -0,0 +1,236 @@
+package search
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// openIndex opens an empty index in a temporary directory
+func openIndex(t *testing.T) *Index {
+	t.Helper()
+	idx, err := Open(filepath.Join(t.TempDir(), IndexFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return idx
+}
+
+// searchIDs returns the IDs of the tabs matching query, best first
+func searchIDs(t *testing.T, idx *Index, query string) []string {
+	t.Helper()
+	results, err := idx.Search(query)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", query, err)
+	}
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.TabID)
+	}
+	return ids
+}
+
+var testContents = []types.TabContent{
+	{TabID: "f.1.1", Title: "Go generics tutorial", URL: "https://go.dev/doc/tutorial/generics", Content: "Type parameters let functions work on many types."},
+	{TabID: "f.1.2", Title: "Cooking pasta", URL: "https://food.example/pasta", Content: "Boil water. Go generics are not needed to cook pasta, but go fast."},
+	{TabID: "f.1.3", Title: "Release notes", URL: "https://go.dev/doc/go1.18", Content: "Generics arrive in this release with type parameters. " + strings.Repeat("Other changes. ", 100)},
+	{TabID: "c.1.1", Title: "Type systems", URL: "https://types.example", Content: "A tour of type parameters in several languages."},
+}
+
+func TestSearchRanking(t *testing.T) {
+	idx := openIndex(t)
+	if err := idx.IndexTabs(testContents); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Title words count triple; a short document beats a long one
+		{"generics", []string{"f.1.1", "f.1.2", "f.1.3"}},
+		// Every word must match, in any order and case
+		{"TYPE parameters", []string{"c.1.1", "f.1.1", "f.1.3"}},
+		{"generics pasta", []string{"f.1.2"}},
+		{"generics haskell", nil},
+		// URLs are indexed too
+		{"food", []string{"f.1.2"}},
+		// Quoted phrases must appear verbatim in the title or the content
+		{`"type parameters let"`, []string{"f.1.1"}},
+		{`generics "go fast"`, []string{"f.1.2"}},
+		{`"parameters type"`, nil},
+		{`"go   generics"`, []string{"f.1.1", "f.1.2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := searchIDs(t, idx, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchEmptyQuery(t *testing.T) {
+	idx := openIndex(t)
+	for _, query := range []string{"", "  ", `""`, "!?"} {
+		if _, err := idx.Search(query); err == nil {
+			t.Errorf("Search(%q) succeeded, want an error", query)
+		}
+	}
+}
+
+func TestScoreBM25(t *testing.T) {
+	idx := openIndex(t)
+	idx.add(types.TabContent{TabID: "f.1.1", Content: "apple apple banana"})
+	idx.add(types.TabContent{TabID: "f.1.2", Content: "banana cherry"})
+
+	// apple: n=2, df=1, tf=2, docLen=3, avgLen=2.5
+	idf := 0.6931471805599453 // ln(1 + 1.5/1.5)
+	want := idf * 2 * (bm25K1 + 1) / (2 + bm25K1*(1-bm25B+bm25B*3/2.5))
+	if got := idx.score("f.1.1", []string{"apple"}); got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("score = %v, want %v", got, want)
+	}
+
+	// A term in every document still scores above zero
+	if got := idx.score("f.1.2", []string{"banana"}); got <= 0 {
+		t.Errorf("score of a common term = %v, want > 0", got)
+	}
+}
+
+func TestIndexTabsReplacesPerPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), IndexFileName)
+	idx, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexTabs(testContents); err != nil {
+		t.Fatal(err)
+	}
+
+	// Firefox now has one tab, with new text; Chrome is untouched
+	if err := idx.IndexTabs([]types.TabContent{{TabID: "f.1.2", Title: "Cooking rice", Content: "Rinse the rice."}}); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("Len = %d, want 2", idx.Len())
+	}
+	if got := searchIDs(t, idx, "pasta"); got != nil {
+		t.Errorf("old text still found: %v", got)
+	}
+	if got := searchIDs(t, idx, "rice"); !reflect.DeepEqual(got, []string{"f.1.2"}) {
+		t.Errorf("Search(rice) = %v", got)
+	}
+	if got := searchIDs(t, idx, "type"); !reflect.DeepEqual(got, []string{"c.1.1"}) {
+		t.Errorf("Search(type) = %v, want the Chrome tab only", got)
+	}
+
+	// The index reads back from disk the same
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 2 || reopened.totalLen != idx.totalLen {
+		t.Errorf("reopened index has %d tabs of %d words, want %d of %d", reopened.Len(), reopened.totalLen, idx.Len(), idx.totalLen)
+	}
+	if got := searchIDs(t, reopened, "rice"); !reflect.DeepEqual(got, []string{"f.1.2"}) {
+		t.Errorf("Search(rice) after reopening = %v", got)
+	}
+	if reopened.Updated().IsZero() {
+		t.Error("Updated is zero after reopening")
+	}
+}
+
+func TestUpdateIndex(t *testing.T) {
+	idx := openIndex(t)
+	if err := idx.IndexTabs(testContents); err != nil {
+		t.Fatal(err)
+	}
+	before := idx.totalLen
+
+	if err := idx.UpdateIndex("f.1.1", types.TabContent{Title: "Go", Content: "short"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := searchIDs(t, idx, "short"); !reflect.DeepEqual(got, []string{"f.1.1"}) {
+		t.Errorf("Search(short) = %v", got)
+	}
+	if got := searchIDs(t, idx, "tutorial"); got != nil {
+		t.Errorf("old text of the updated tab still found: %v", got)
+	}
+	if idx.Len() != len(testContents) || idx.totalLen >= before {
+		t.Errorf("Len, words = %d, %d after update, want %d and fewer than %d", idx.Len(), idx.totalLen, len(testContents), before)
+	}
+}
+
+func TestContentCapKeepsUTF8(t *testing.T) {
+	idx := openIndex(t)
+	// "é" is two bytes and straddles the cap
+	content := strings.Repeat("a", maxContentBytes-1) + "é" + "tail"
+	idx.add(types.TabContent{TabID: "f.1.1", Content: content})
+
+	stored := idx.docs["f.1.1"].content.Content
+	if len(stored) != maxContentBytes-1 || !utf8.ValidString(stored) {
+		t.Errorf("capped content is %d bytes, valid UTF-8 %v; want %d valid bytes", len(stored), utf8.ValidString(stored), maxContentBytes-1)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("word ", 40) + "needle" + strings.Repeat(" word", 60)
+	tests := []struct {
+		name    string
+		content string
+		terms   []string
+		want    string
+	}{
+		{"short content", "a  needle\nin text", []string{"needle"}, "a needle in text"},
+		{"no match starts at the beginning", "just text", []string{"needle"}, "just text"},
+		{"case", "Find the NEEDLE", []string{"needle"}, "Find the NEEDLE"},
+		{"first of any term", "one two three", []string{"three", "two"}, "one two three"},
+		{"long content", long, []string{"needle"}, "…" + strings.Join(strings.Fields(long[200-snippetBefore:400-snippetBefore]), " ") + "…"},
+		{"term with regexp characters", "costs $5 (approx.)", []string{"(approx"}, "costs $5 (approx.)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snippet(tt.content, tt.terms); got != tt.want {
+				t.Errorf("snippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnippetUTF8(t *testing.T) {
+	// Multi-byte runes on both cut points
+	content := strings.Repeat("日本語", 30) + "needle" + strings.Repeat("émigré ", 40)
+	got := snippet(content, []string{"needle"})
+	if !utf8.ValidString(got) {
+		t.Fatalf("snippet %q is not valid UTF-8", got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "needle") {
+		t.Errorf("snippet = %q, want the text around needle with ellipses", got)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query   string
+		terms   []string
+		phrases []string
+	}{
+		{"Go generics", []string{"go", "generics"}, nil},
+		{`"type  parameters" go`, []string{"type", "parameters", "go"}, []string{"type parameters"}},
+		{`say "hi`, []string{"say", "hi"}, []string{"hi"}},
+		{`c++ and c#`, []string{"c", "and", "c"}, nil},
+		{`naïve café`, []string{"naïve", "café"}, nil},
+	}
+	for _, tt := range tests {
+		terms, phrases := parseQuery(tt.query)
+		if !reflect.DeepEqual(terms, tt.terms) || !reflect.DeepEqual(phrases, tt.phrases) {
+			t.Errorf("parseQuery(%q) = %q, %q; want %q, %q", tt.query, terms, phrases, tt.terms, tt.phrases)
+		}
+	}
+}
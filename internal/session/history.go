@@ -0,0 +1,165 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tabctl/tabctl/internal/platform"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// HistoryDirName is the directory of session snapshots in the cache directory
+const HistoryDirName = "history"
+
+// snapshotLayout names snapshots after the time they were taken, in UTC, so
+// names sort chronologically
+const snapshotLayout = "20060102T150405Z"
+
+// History is a rotating set of session snapshots, oldest first
+type History struct {
+	store  *Store
+	keep   int           // snapshots kept at most; 0 means no limit
+	maxAge time.Duration // snapshots older than this are dropped; 0 means no limit
+}
+
+// NewHistory returns the history kept in dir with the given retention limits
+func NewHistory(dir string, keep int, maxAge time.Duration) *History {
+	return &History{store: NewStore(dir), keep: keep, maxAge: maxAge}
+}
+
+// DefaultHistory returns the history in the cache directory
+func DefaultHistory(keep int, maxAge time.Duration) (*History, error) {
+	cacheDir, err := platform.GetCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewHistory(filepath.Join(cacheDir, HistoryDirName), keep, maxAge), nil
+}
+
+// Dir returns the directory the snapshots are kept in
+func (h *History) Dir() string {
+	return h.store.Dir()
+}
+
+// SnapshotName returns the name of a snapshot taken at t
+func SnapshotName(t time.Time) string {
+	return t.UTC().Format(snapshotLayout)
+}
+
+// Snapshot stores sess as a snapshot named after its creation time, then
+// applies the retention limits. A session with the same tabs as the latest
+// snapshot is not stored again; Snapshot then returns false.
+func (h *History) Snapshot(sess types.Session) (bool, error) {
+	names, err := h.names()
+	if err != nil {
+		return false, err
+	}
+	if len(names) > 0 {
+		latest, err := h.store.Load(names[len(names)-1])
+		if err == nil && reflect.DeepEqual(latest.Browsers, roundTrip(sess).Browsers) {
+			return false, nil
+		}
+	}
+
+	sess.Name = SnapshotName(sess.Created)
+	if err := h.store.Save(sess); err != nil {
+		return false, err
+	}
+	return true, h.Prune(time.Now())
+}
+
+// roundTrip returns sess as it reads back from disk, so it compares equal
+// to a stored snapshot of the same tabs
+func roundTrip(sess types.Session) types.Session {
+	data, err := json.Marshal(sess)
+	if err != nil {
+		return sess
+	}
+	var copied types.Session
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return sess
+	}
+	return copied
+}
+
+// Prune drops the snapshots beyond the retention limits as of now
+func (h *History) Prune(now time.Time) error {
+	names, err := h.names()
+	if err != nil {
+		return err
+	}
+
+	drop := 0
+	if h.keep > 0 && len(names) > h.keep {
+		drop = len(names) - h.keep
+	}
+	if h.maxAge > 0 {
+		cutoff := now.Add(-h.maxAge)
+		for drop < len(names) {
+			taken, err := time.Parse(snapshotLayout, names[drop])
+			if err != nil || !taken.Before(cutoff) {
+				break
+			}
+			drop++
+		}
+	}
+
+	for _, name := range names[:drop] {
+		if err := h.store.Delete(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns every snapshot, oldest first
+func (h *History) List() ([]types.Session, error) {
+	return h.store.List()
+}
+
+// At returns the latest snapshot taken at or before t
+func (h *History) At(t time.Time) (types.Session, error) {
+	names, err := h.names()
+	if err != nil {
+		return types.Session{}, err
+	}
+
+	target := SnapshotName(t)
+	i := sort.Search(len(names), func(i int) bool { return names[i] > target })
+	if i == 0 {
+		return types.Session{}, fmt.Errorf("no snapshot at or before %s", t.Local().Format(time.RFC3339))
+	}
+	return h.store.Load(names[i-1])
+}
+
+// names returns the names of the stored snapshots, oldest first
+func (h *History) names() ([]string, error) {
+	entries, err := os.ReadDir(h.store.Dir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), fileExt)
+		if entry.IsDir() || name == entry.Name() {
+			continue
+		}
+		if _, err := time.Parse(snapshotLayout, name); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
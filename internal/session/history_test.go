@@ -0,0 +1,171 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// base is the time of the first snapshot in these tests
+var base = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// snapshotAt stores a snapshot of one tab with url taken at base + offset
+func snapshotAt(t *testing.T, h *History, offset time.Duration, url string) bool {
+	t.Helper()
+	sess := testSession("Firefox", []string{url})
+	sess.Created = base.Add(offset)
+	stored, err := h.Snapshot(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+// snapshotNames returns the names of the snapshots in h, oldest first
+func snapshotNames(t *testing.T, h *History) []string {
+	t.Helper()
+	names, err := h.names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestSnapshotSkipsUnchanged(t *testing.T) {
+	h := NewHistory(t.TempDir(), 0, 0)
+
+	steps := []struct {
+		offset time.Duration
+		url    string
+		stored bool
+	}{
+		{0, "https://a.example", true},
+		{time.Minute, "https://a.example", false},
+		{2 * time.Minute, "https://b.example", true},
+		{3 * time.Minute, "https://a.example", true}, // only the latest counts
+	}
+	for _, step := range steps {
+		if stored := snapshotAt(t, h, step.offset, step.url); stored != step.stored {
+			t.Errorf("snapshot of %s at +%s stored = %v, want %v", step.url, step.offset, stored, step.stored)
+		}
+	}
+
+	want := []string{"20260301T120000Z", "20260301T120200Z", "20260301T120300Z"}
+	if got := snapshotNames(t, h); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshots = %v, want %v", got, want)
+	}
+}
+
+func TestSnapshotKeepsCount(t *testing.T) {
+	h := NewHistory(t.TempDir(), 2, 0)
+	for i, url := range []string{"https://a.example", "https://b.example", "https://c.example"} {
+		snapshotAt(t, h, time.Duration(i)*time.Hour, url)
+	}
+
+	want := []string{"20260301T130000Z", "20260301T140000Z"}
+	if got := snapshotNames(t, h); !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshots = %v, want %v", got, want)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name   string
+		keep   int
+		maxAge time.Duration
+		want   []int // hours after base of the snapshots left
+	}{
+		{"no limits", 0, 0, []int{0, 1, 2, 3, 4}},
+		{"count", 3, 0, []int{2, 3, 4}},
+		{"count above", 10, 0, []int{0, 1, 2, 3, 4}},
+		{"age", 0, 100 * time.Minute, []int{3, 4}},
+		{"age at the cutoff", 0, 150 * time.Minute, []int{2, 3, 4}},
+		{"count and age", 4, 90 * time.Minute, []int{3, 4}},
+		{"age and count", 1, 150 * time.Minute, []int{4}},
+		{"age beyond every snapshot", 0, time.Minute, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			all := NewHistory(dir, 0, 0)
+			for i := 0; i < 5; i++ {
+				snapshotAt(t, all, time.Duration(i)*time.Hour, fmt.Sprintf("https://example.com/%d", i))
+			}
+
+			// now is the time of the last snapshot + 30 minutes
+			h := NewHistory(dir, tt.keep, tt.maxAge)
+			if err := h.Prune(base.Add(4*time.Hour + 30*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+
+			var want []string
+			for _, hour := range tt.want {
+				want = append(want, SnapshotName(base.Add(time.Duration(hour)*time.Hour)))
+			}
+			if got := snapshotNames(t, h); !reflect.DeepEqual(got, want) {
+				t.Errorf("snapshots = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestHistoryAt(t *testing.T) {
+	h := NewHistory(t.TempDir(), 0, 0)
+	snapshotAt(t, h, 0, "https://a.example")
+	snapshotAt(t, h, time.Hour, "https://b.example")
+	snapshotAt(t, h, 2*time.Hour, "https://c.example")
+
+	tests := []struct {
+		at   time.Time
+		want string // URL of the snapshot found
+	}{
+		{base, "https://a.example"},
+		{base.Add(59 * time.Minute), "https://a.example"},
+		{base.Add(time.Hour), "https://b.example"},
+		{base.Add(90 * time.Minute).In(time.FixedZone("UTC+5", 5*3600)), "https://b.example"},
+		{base.Add(24 * time.Hour), "https://c.example"},
+	}
+	for _, tt := range tests {
+		sess, err := h.At(tt.at)
+		if err != nil {
+			t.Errorf("At(%s): %v", tt.at, err)
+			continue
+		}
+		if got := sess.Browsers[0].Windows[0].Tabs[0].URL; got != tt.want {
+			t.Errorf("At(%s) = snapshot of %s, want %s", tt.at, got, tt.want)
+		}
+	}
+
+	if _, err := h.At(base.Add(-time.Second)); err == nil || !strings.Contains(err.Error(), "no snapshot at or before") {
+		t.Errorf("At before the first snapshot: error = %v, want no snapshot", err)
+	}
+}
+
+func TestHistoryIgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	h := NewHistory(dir, 0, 0)
+	snapshotAt(t, h, 0, "https://a.example")
+
+	for _, name := range []string{"work.json", "20260301T120000Z.json.tmp", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "20260301T130000Z.json"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := snapshotNames(t, h); !reflect.DeepEqual(got, []string{"20260301T120000Z"}) {
+		t.Errorf("snapshots = %v, want only 20260301T120000Z", got)
+	}
+
+	// A missing directory is an empty history
+	if names, err := NewHistory(filepath.Join(dir, "none"), 0, 0).names(); err != nil || len(names) != 0 {
+		t.Errorf("names of a missing directory = %v, %v", names, err)
+	}
+}
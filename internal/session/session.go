@@ -0,0 +1,289 @@
+// Package session saves the windows and tabs of the connected browsers and
+// reopens them later.
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tabctl/tabctl/internal/client"
+	"github.com/tabctl/tabctl/internal/dbus"
+	"github.com/tabctl/tabctl/pkg/api"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// Capture records the windows and tabs of every client, asking all of them
+// at once. Browsers that fail to answer are left out; Capture only fails if
+// none answers.
+func Capture(name string, clients []api.Client) (types.Session, error) {
+	sess := types.Session{Name: name, Created: time.Now()}
+
+	var lastErr error
+	for _, reply := range client.FanOut(clients, api.Client.ListTabs) {
+		if reply.Err != nil {
+			lastErr = reply.Err
+			continue
+		}
+		sess.Browsers = append(sess.Browsers, types.BrowserSession{
+			Browser: reply.Client.GetBrowser(),
+			Windows: groupWindows(reply.Value),
+		})
+	}
+
+	if len(sess.Browsers) == 0 && lastErr != nil {
+		return types.Session{}, fmt.Errorf("failed to list tabs: %w", lastErr)
+	}
+
+	sort.Slice(sess.Browsers, func(i, j int) bool { return sess.Browsers[i].Browser < sess.Browsers[j].Browser })
+	return sess, nil
+}
+
+// groupWindows groups tabs by window, in window and tab order
+func groupWindows(tabs []types.Tab) []types.WindowSession {
+	byWindow := make(map[int][]types.Tab)
+	for _, tab := range tabs {
+		byWindow[tab.WindowID] = append(byWindow[tab.WindowID], tab)
+	}
+
+	windows := make([]types.WindowSession, 0, len(byWindow))
+	for windowID, windowTabs := range byWindow {
+		sort.SliceStable(windowTabs, func(i, j int) bool { return windowTabs[i].Index < windowTabs[j].Index })
+
+		window := types.WindowSession{ID: windowID}
+		for _, tab := range windowTabs {
+			window.Tabs = append(window.Tabs, types.SessionTab{
+				Title:  tab.Title,
+				URL:    tab.URL,
+				Pinned: tab.Pinned,
+				Active: tab.Active,
+			})
+		}
+		windows = append(windows, window)
+	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i].ID < windows[j].ID })
+	return windows
+}
+
+// TabCount returns the number of tabs in a session
+func TabCount(sess types.Session) int {
+	count := 0
+	for _, browser := range sess.Browsers {
+		for _, window := range browser.Windows {
+			count += len(window.Tabs)
+		}
+	}
+	return count
+}
+
+// WindowCount returns the number of windows in a session
+func WindowCount(sess types.Session) int {
+	count := 0
+	for _, browser := range sess.Browsers {
+		count += len(browser.Windows)
+	}
+	return count
+}
+
+// RestoreResult reports where the windows of one saved browser were reopened
+type RestoreResult struct {
+	Browser string   `json:"browser"`           // browser in the session
+	Target  string   `json:"target"`            // browser the tabs were opened in
+	Windows int      `json:"windows"`           // windows opened
+	TabIDs  []string `json:"tab_ids"`           // tabs opened
+	Skipped []string `json:"skipped,omitempty"` // URLs browsers do not let extensions open
+	Error   string   `json:"error,omitempty"`
+}
+
+// Restore reopens every window of the session in a new window. Each saved
+// browser is restored into the connected browser of the same name, else the
+// same browser with another profile or instance, else the one SelectClient
+// picks for preferred. Pinned tabs are pinned again once opened. Tabs of
+// pages extensions may not open, such as about:preferences or file: URLs,
+// are skipped.
+func Restore(bm *client.BrowserManager, sess types.Session, preferred string) ([]RestoreResult, error) {
+	if len(bm.GetClients()) == 0 {
+		return nil, client.ErrNoBrowsers
+	}
+
+	var results []RestoreResult
+	for _, browser := range sess.Browsers {
+		result := RestoreResult{Browser: browser.Browser}
+
+		target, err := restoreTarget(bm, browser.Browser, preferred)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Target = target.GetBrowser()
+
+		for _, window := range browser.Windows {
+			window, skipped := reopenableTabs(window)
+			result.Skipped = append(result.Skipped, skipped...)
+
+			tabIDs, err := restoreWindow(target, window)
+			result.TabIDs = append(result.TabIDs, tabIDs...)
+			if len(tabIDs) > 0 {
+				result.Windows++
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// restoreTarget picks the connected browser to restore a saved browser into
+func restoreTarget(bm *client.BrowserManager, saved, preferred string) (api.Client, error) {
+	clients := bm.GetClients()
+	for _, c := range clients {
+		if c.GetBrowser() == saved {
+			return c, nil
+		}
+	}
+
+	savedBrowser := dbus.ParseBrowserName(saved).Browser
+	for _, c := range clients {
+		if strings.EqualFold(dbus.ParseBrowserName(c.GetBrowser()).Browser, savedBrowser) {
+			return c, nil
+		}
+	}
+
+	return bm.SelectClient("", preferred)
+}
+
+// reopenableTabs drops the tabs of a window an extension cannot open and
+// returns their URLs
+func reopenableTabs(window types.WindowSession) (types.WindowSession, []string) {
+	var tabs []types.SessionTab
+	var skipped []string
+	for _, tab := range window.Tabs {
+		if reopenable(tab.URL) {
+			tabs = append(tabs, tab)
+		} else {
+			skipped = append(skipped, tab.URL)
+		}
+	}
+	window.Tabs = tabs
+	return window, skipped
+}
+
+// reopenable reports whether browsers let an extension open url in a tab.
+// Firefox refuses privileged pages (about:newtab, about:preferences) and
+// file: URLs, and one refused URL would fail its whole window.
+func reopenable(url string) bool {
+	if url == "about:blank" {
+		return true
+	}
+	scheme, _, ok := strings.Cut(url, ":")
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "ftp":
+		return true
+	}
+	return false
+}
+
+// restoreWindow opens the tabs of a saved window in a new window and pins
+// the ones that were pinned
+func restoreWindow(target api.Client, window types.WindowSession) ([]string, error) {
+	urls := make([]string, 0, len(window.Tabs))
+	for _, tab := range window.Tabs {
+		urls = append(urls, tab.URL)
+	}
+	if len(urls) == 0 {
+		return nil, nil
+	}
+
+	tabIDs, err := target.OpenURLs(urls, target.GetPrefix()+"0", types.OpenOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open window: %w", err)
+	}
+
+	// Tab IDs come back in URL order; without one per URL they can't be matched
+	if len(tabIDs) != len(urls) {
+		return tabIDs, nil
+	}
+
+	var updates []types.TabUpdate
+	for i, tab := range window.Tabs {
+		if tab.Pinned {
+			updates = append(updates, types.TabUpdate{
+				TabID:      tabIDs[i],
+				Properties: map[string]interface{}{"pinned": true},
+			})
+		}
+	}
+	if len(updates) > 0 {
+		if err := target.UpdateTabs(updates); err != nil {
+			return tabIDs, fmt.Errorf("failed to pin tabs: %w", err)
+		}
+	}
+
+	return tabIDs, nil
+}
+
+// Change is a difference between a saved session and the open tabs
+type Change struct {
+	Kind    string `json:"change"` // ChangeMissing or ChangeAdded
+	Browser string `json:"browser"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+}
+
+const (
+	// ChangeMissing marks a saved tab that is no longer open
+	ChangeMissing = "missing"
+	// ChangeAdded marks an open tab that is not in the session
+	ChangeAdded = "added"
+)
+
+// Diff compares a saved session with the current one by URL, normalized as
+// for duplicate detection. Windows and browsers are ignored; a URL saved
+// twice but open once is reported missing once.
+func Diff(saved, current types.Session) []Change {
+	savedTabs := flatten(saved, ChangeMissing)
+	openTabs := flatten(current, ChangeAdded)
+	return append(subtract(savedTabs, openTabs), subtract(openTabs, savedTabs)...)
+}
+
+// subtract returns the tabs of a whose URL is not matched by a tab of b,
+// each tab of b matching at most once
+func subtract(a, b []Change) []Change {
+	counts := make(map[string]int)
+	for _, change := range b {
+		counts[client.NormalizeURL(change.URL)]++
+	}
+
+	var rest []Change
+	for _, change := range a {
+		key := client.NormalizeURL(change.URL)
+		if counts[key] > 0 {
+			counts[key]--
+			continue
+		}
+		rest = append(rest, change)
+	}
+	return rest
+}
+
+// flatten lists the tabs of a session as changes of the given kind
+func flatten(sess types.Session, kind string) []Change {
+	var changes []Change
+	for _, browser := range sess.Browsers {
+		for _, window := range browser.Windows {
+			for _, tab := range window.Tabs {
+				changes = append(changes, Change{Kind: kind, Browser: browser.Browser, Title: tab.Title, URL: tab.URL})
+			}
+		}
+	}
+	return changes
+}
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// testSession returns a session of one browser with a window per list of URLs
+func testSession(browser string, windows ...[]string) types.Session {
+	sess := types.Session{Browsers: []types.BrowserSession{{Browser: browser}}}
+	for i, urls := range windows {
+		window := types.WindowSession{ID: i + 1}
+		for _, url := range urls {
+			window.Tabs = append(window.Tabs, types.SessionTab{Title: "title of " + url, URL: url})
+		}
+		sess.Browsers[0].Windows = append(sess.Browsers[0].Windows, window)
+	}
+	return sess
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		saved   types.Session
+		current types.Session
+		want    []string // kind and URL of each change, in order
+	}{
+		{
+			name:    "same tabs in other windows",
+			saved:   testSession("Firefox", []string{"https://a.example", "https://b.example"}),
+			current: testSession("Firefox", []string{"https://b.example"}, []string{"https://a.example"}),
+			want:    nil,
+		},
+		{
+			name:    "missing and added",
+			saved:   testSession("Firefox", []string{"https://a.example", "https://b.example"}),
+			current: testSession("Firefox", []string{"https://b.example", "https://c.example"}),
+			want:    []string{"missing https://a.example", "added https://c.example"},
+		},
+		{
+			name:    "URLs compare normalized",
+			saved:   testSession("Firefox", []string{"https://a.example/page/", "https://b.example/?utm_source=x"}),
+			current: testSession("Firefox", []string{"https://A.example/page#top", "https://b.example"}),
+			want:    nil,
+		},
+		{
+			name:    "saved twice, open once",
+			saved:   testSession("Firefox", []string{"https://a.example", "https://a.example", "https://a.example"}),
+			current: testSession("Firefox", []string{"https://a.example"}),
+			want:    []string{"missing https://a.example", "missing https://a.example"},
+		},
+		{
+			name:    "open twice, saved once",
+			saved:   testSession("Firefox", []string{"https://a.example"}),
+			current: testSession("Firefox", []string{"https://a.example"}, []string{"https://a.example"}),
+			want:    []string{"added https://a.example"},
+		},
+		{
+			name:    "browsers are ignored",
+			saved:   testSession("Firefox", []string{"https://a.example"}),
+			current: testSession("Chrome", []string{"https://a.example"}),
+			want:    nil,
+		},
+		{
+			name:    "empty session",
+			saved:   types.Session{},
+			current: testSession("Chrome", []string{"https://a.example"}),
+			want:    []string{"added https://a.example"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, change := range Diff(tt.saved, tt.current) {
+				got = append(got, change.Kind+" "+change.URL)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffKeepsBrowserAndTitle(t *testing.T) {
+	changes := Diff(testSession("Firefox:work", []string{"https://a.example"}), types.Session{})
+	want := []Change{{Kind: ChangeMissing, Browser: "Firefox:work", Title: "title of https://a.example", URL: "https://a.example"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff = %+v, want %+v", changes, want)
+	}
+}
+
+func TestReopenable(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com", true},
+		{"http://example.com", true},
+		{"HTTPS://example.com", true},
+		{"ftp://example.com/file", true},
+		{"about:blank", true},
+		{"about:newtab", false},
+		{"about:preferences", false},
+		{"file:///home/me/notes.txt", false},
+		{"chrome://settings", false},
+		{"moz-extension://abcd/page.html", false},
+		{"javascript:alert(1)", false},
+		{"data:text/plain,hi", false},
+		{"example.com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := reopenable(tt.url); got != tt.want {
+			t.Errorf("reopenable(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestReopenableTabs(t *testing.T) {
+	window := testSession("Firefox", []string{"about:preferences", "https://a.example", "file:///tmp/x", "https://b.example"}).Browsers[0].Windows[0]
+	window.Tabs[1].Pinned = true
+
+	kept, skipped := reopenableTabs(window)
+
+	if kept.ID != window.ID {
+		t.Errorf("window ID = %d, want %d", kept.ID, window.ID)
+	}
+	var urls []string
+	for _, tab := range kept.Tabs {
+		urls = append(urls, tab.URL)
+	}
+	if want := []string{"https://a.example", "https://b.example"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("kept %v, want %v", urls, want)
+	}
+	if !kept.Tabs[0].Pinned {
+		t.Error("kept tab lost its pinned state")
+	}
+	if want := []string{"about:preferences", "file:///tmp/x"}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped %v, want %v", skipped, want)
+	}
+	if len(window.Tabs) != 4 {
+		t.Errorf("the saved window changed to %d tabs", len(window.Tabs))
+	}
+}
+
+func TestGroupWindows(t *testing.T) {
+	windows := groupWindows([]types.Tab{
+		{URL: "https://c.example", WindowID: 7, Index: 1},
+		{URL: "https://a.example", WindowID: 3, Index: 0, Pinned: true},
+		{URL: "https://b.example", WindowID: 7, Index: 0, Active: true},
+	})
+
+	want := []types.WindowSession{
+		{ID: 3, Tabs: []types.SessionTab{{URL: "https://a.example", Pinned: true}}},
+		{ID: 7, Tabs: []types.SessionTab{{URL: "https://b.example", Active: true}, {URL: "https://c.example"}}},
+	}
+	if !reflect.DeepEqual(windows, want) {
+		t.Errorf("groupWindows = %+v, want %+v", windows, want)
+	}
+
+	sess := types.Session{Browsers: []types.BrowserSession{{Windows: windows}, {Windows: windows[:1]}}}
+	if TabCount(sess) != 4 || WindowCount(sess) != 3 {
+		t.Errorf("TabCount, WindowCount = %d, %d, want 4, 3", TabCount(sess), WindowCount(sess))
+	}
+}
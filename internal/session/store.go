@@ -0,0 +1,157 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tabctl/tabctl/internal/platform"
+	"github.com/tabctl/tabctl/pkg/types"
+)
+
+// SessionsDirName is the directory of saved sessions in the config directory
+const SessionsDirName = "sessions"
+
+const fileExt = ".json"
+
+// validName keeps session names usable as file names
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Store keeps sessions as JSON files in a directory, one file per session
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store over dir. The directory is created on the first
+// save.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// DefaultStore returns the store of named sessions in the config directory
+func DefaultStore() (*Store, error) {
+	configDir, err := platform.GetConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(filepath.Join(configDir, SessionsDirName)), nil
+}
+
+// Dir returns the directory the store keeps its files in
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// ValidateName checks that name can be used as a session name
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid session name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// Exists reports whether a session with that name is stored
+func (s *Store) Exists(name string) bool {
+	_, err := os.Stat(s.path(name))
+	return err == nil
+}
+
+// Save writes the session atomically, replacing one with the same name
+func (s *Store) Save(sess types.Session) error {
+	if err := ValidateName(sess.Name); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	if err := platform.EnsureDir(s.dir); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	path := s.path(sess.Name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write session %s: %w", sess.Name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write session %s: %w", sess.Name, err)
+	}
+	return nil
+}
+
+// Load reads the named session
+func (s *Store) Load(name string) (types.Session, error) {
+	if err := ValidateName(name); err != nil {
+		return types.Session{}, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return types.Session{}, fmt.Errorf("session %q not found", name)
+	}
+	if err != nil {
+		return types.Session{}, fmt.Errorf("failed to read session %s: %w", name, err)
+	}
+
+	var sess types.Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return types.Session{}, fmt.Errorf("failed to parse session %s: %w", name, err)
+	}
+	sess.Name = name
+	return sess, nil
+}
+
+// List returns every stored session, sorted by name. Files that fail to
+// parse are skipped.
+func (s *Store) List() ([]types.Session, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session directory: %w", err)
+	}
+
+	var sessions []types.Session
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), fileExt)
+		if entry.IsDir() || name == entry.Name() || ValidateName(name) != nil {
+			continue
+		}
+		sess, err := s.Load(name)
+		if err != nil {
+			continue
+		}
+		sessions = append(sessions, sess)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Name < sessions[j].Name })
+	return sessions, nil
+}
+
+// Delete removes the named session
+func (s *Store) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("session %q not found", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+fileExt)
+}
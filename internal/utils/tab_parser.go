@@ -116,10 +116,11 @@ func ValidateTabID(tabID string) error {
 	return err
 }
 
-// GetTabPrefix extracts the prefix from a tab ID
+// GetTabPrefix extracts the prefix, including its dot, from a tab or window
+// ID ("f.1.2" -> "f.", "lw.3" -> "lw.")
 func GetTabPrefix(tabID string) string {
-	if len(tabID) >= 2 && tabID[1] == '.' {
-		return tabID[:2]
+	if i := strings.Index(tabID, "."); i > 0 {
+		return tabID[:i+1]
 	}
 	return ""
 }
@@ -10,7 +10,7 @@ type TabAPI interface {
 	ListTabs() ([]types.Tab, error)
 	CloseTabs(tabIDs []string) error
 	ActivateTab(tabID string, focused bool) error
-	MoveTabs() error
+	MoveTabs(moves []types.TabMove) error
 
 	// Tab state operations
 	UpdateTabs(updates []types.TabUpdate) error
@@ -28,10 +28,10 @@ type TabAPI interface {
 	GetActiveTabs() ([]string, error)
 
 	// URL operations
-	OpenURLs(urls []string, windowID string) ([]string, error)
+	OpenURLs(urls []string, windowID string, options types.OpenOptions) ([]string, error)
 
-	// Screenshot operations
-	GetScreenshot() (*types.Screenshot, error)
+	// Screenshot operations; windowID "" means the last focused window
+	GetScreenshot(windowID string) (*types.Screenshot, error)
 }
 
 // SearchAPI defines the interface for search operations
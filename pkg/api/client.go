@@ -2,6 +2,8 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/tabctl/tabctl/pkg/types"
 )
@@ -69,13 +71,18 @@ func (mc *multiClient) CloseTabs(tabIDs []string) error {
 	}
 
 	// Execute close on each client
+	var errs []error
 	for prefix, tabs := range clientTabs {
 		client := mc.getClientByPrefix(prefix)
-		if client != nil {
-			client.CloseTabs(tabs)
+		if client == nil {
+			errs = append(errs, fmt.Errorf("no client found for prefix %s", prefix))
+			continue
+		}
+		if err := client.CloseTabs(tabs); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (mc *multiClient) ActivateTab(tabID string, focused bool) error {
@@ -87,9 +94,27 @@ func (mc *multiClient) ActivateTab(tabID string, focused bool) error {
 	return client.ActivateTab(tabID, focused)
 }
 
-func (mc *multiClient) MoveTabs() error {
-	// TODO: Implement move tabs across all clients
-	return errors.New("not implemented yet")
+func (mc *multiClient) MoveTabs(moves []types.TabMove) error {
+	// Group moves by client prefix
+	clientMoves := make(map[string][]types.TabMove)
+	for _, move := range moves {
+		prefix := getTabPrefix(move.TabID)
+		clientMoves[prefix] = append(clientMoves[prefix], move)
+	}
+
+	// Execute moves on each client
+	var errs []error
+	for prefix, moves := range clientMoves {
+		client := mc.getClientByPrefix(prefix)
+		if client == nil {
+			errs = append(errs, fmt.Errorf("no client found for prefix %s", prefix))
+			continue
+		}
+		if err := client.MoveTabs(moves); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (mc *multiClient) UpdateTabs(updates []types.TabUpdate) error {
@@ -101,13 +126,18 @@ func (mc *multiClient) UpdateTabs(updates []types.TabUpdate) error {
 	}
 
 	// Execute updates on each client
+	var errs []error
 	for prefix, updates := range clientUpdates {
 		client := mc.getClientByPrefix(prefix)
-		if client != nil {
-			client.UpdateTabs(updates)
+		if client == nil {
+			errs = append(errs, fmt.Errorf("no client found for prefix %s", prefix))
+			continue
+		}
+		if err := client.UpdateTabs(updates); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (mc *multiClient) QueryTabs(query types.TabQuery) ([]types.Tab, error) {
@@ -131,13 +161,18 @@ func (mc *multiClient) NavigateURLs(pairs []types.TabURLPair) error {
 	}
 
 	// Execute navigation on each client
+	var errs []error
 	for prefix, pairs := range clientPairs {
 		client := mc.getClientByPrefix(prefix)
-		if client != nil {
-			client.NavigateURLs(pairs)
+		if client == nil {
+			errs = append(errs, fmt.Errorf("no client found for prefix %s", prefix))
+			continue
+		}
+		if err := client.NavigateURLs(pairs); err != nil {
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (mc *multiClient) GetText(tabIDs []string, options types.TextOptions) ([]types.TabContent, error) {
@@ -223,19 +258,27 @@ func (mc *multiClient) GetActiveTabs() ([]string, error) {
 	return allActive, nil
 }
 
-func (mc *multiClient) OpenURLs(urls []string, windowID string) ([]string, error) {
+func (mc *multiClient) OpenURLs(urls []string, windowID string, options types.OpenOptions) ([]string, error) {
 	prefix := getWindowPrefix(windowID)
 	client := mc.getClientByPrefix(prefix)
 	if client == nil {
 		return nil, errors.New("client not found")
 	}
-	return client.OpenURLs(urls, windowID)
+	return client.OpenURLs(urls, windowID, options)
 }
 
-func (mc *multiClient) GetScreenshot() (*types.Screenshot, error) {
+func (mc *multiClient) GetScreenshot(windowID string) (*types.Screenshot, error) {
+	if windowID != "" {
+		client := mc.getClientByPrefix(getWindowPrefix(windowID))
+		if client == nil {
+			return nil, errors.New("client not found")
+		}
+		return client.GetScreenshot(windowID)
+	}
+
 	// Return screenshot from first available client
 	for _, client := range mc.clients {
-		screenshot, err := client.GetScreenshot()
+		screenshot, err := client.GetScreenshot("")
 		if err == nil {
 			return screenshot, nil
 		}
@@ -265,16 +308,13 @@ func (mc *multiClient) groupTabsByClient(tabIDs []string) map[string][]string {
 // Utility functions
 func getTabPrefix(tabID string) string {
 	// Extract prefix from tab ID (format: prefix.window.tab)
-	if len(tabID) > 0 && tabID[1] == '.' {
-		return tabID[:2] // Return "a.", "b.", etc.
+	if i := strings.Index(tabID, "."); i > 0 {
+		return tabID[:i+1] // Return "f.", "lw.", etc.
 	}
 	return ""
 }
 
 func getWindowPrefix(windowID string) string {
 	// Extract prefix from window ID (format: prefix.window)
-	if len(windowID) > 0 && windowID[1] == '.' {
-		return windowID[:2] // Return "a.", "b.", etc.
-	}
-	return ""
+	return getTabPrefix(windowID)
 }
\ No newline at end of file
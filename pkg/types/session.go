@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+// Session is a saved set of browser windows and their tabs
+type Session struct {
+	Name     string           `json:"name"`
+	Created  time.Time        `json:"created"`
+	Browsers []BrowserSession `json:"browsers"`
+}
+
+// BrowserSession holds the windows of one browser in a session
+type BrowserSession struct {
+	Browser string          `json:"browser"` // mediator name, e.g. "Firefox:work"
+	Windows []WindowSession `json:"windows"`
+}
+
+// WindowSession holds the tabs of one window, in tab order
+type WindowSession struct {
+	ID   int          `json:"id"` // window ID when saved; only groups the tabs
+	Tabs []SessionTab `json:"tabs"`
+}
+
+// SessionTab is a tab in a saved session
+type SessionTab struct {
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Pinned bool   `json:"pinned,omitempty"`
+	Active bool   `json:"active,omitempty"`
+}
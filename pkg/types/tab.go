@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // Tab represents a browser tab
 type Tab struct {
 	ID       string `json:"id"`       // Tab ID in format "prefix.window.tab"
@@ -13,6 +15,31 @@ type Tab struct {
 	Muted    bool   `json:"muted"`
 }
 
+// TabEvent represents a live change reported by a browser
+type TabEvent struct {
+	Time      time.Time `json:"time"`
+	Browser   string    `json:"browser"`
+	Event     string    `json:"event"`
+	TabID     string    `json:"tab_id,omitempty"`
+	WindowID  int       `json:"window_id"`
+	Index     int       `json:"index"`
+	FromIndex int       `json:"from_index,omitempty"`
+	Title     string    `json:"title,omitempty"`
+	URL       string    `json:"url,omitempty"`
+}
+
+// Tab event names
+const (
+	EventCreated      = "created"
+	EventRemoved      = "removed"
+	EventUpdated      = "updated"
+	EventActivated    = "activated"
+	EventMoved        = "moved"
+	EventFocused      = "focused"
+	EventConnected    = "connected"
+	EventDisconnected = "disconnected"
+)
+
 // TabContent represents tab text/html content
 type TabContent struct {
 	TabID   string `json:"tab_id"`
@@ -47,7 +74,8 @@ type TabQuery struct {
 	Index            *int     `json:"index,omitempty"`
 }
 
-// SearchResult represents a search result from SQLite FTS5
+// SearchResult represents a full-text search hit. Rank is the relevance
+// score; higher ranks are better matches.
 type SearchResult struct {
 	TabID   string  `json:"tab_id"`
 	Title   string  `json:"title"`
@@ -86,13 +114,40 @@ type Window struct {
 	TabCount int   `json:"tab_count"`
 }
 
-// TabMove represents a tab move operation
+// OpenOptions controls how new tabs are opened
+type OpenOptions struct {
+	Background bool `json:"background"` // Open without switching to the new tabs
+	Pinned     bool `json:"pinned"`     // Pin the new tabs
+}
+
+// TabMove represents a tab move operation. WindowID 0 moves the tab into a
+// new window and Index -1 places it after the last tab of the window.
 type TabMove struct {
 	TabID    string `json:"tab_id"`
 	WindowID int    `json:"window_id"`
 	Index    int    `json:"index"`
 }
 
+// TabResult is the outcome of an operation on one tab
+type TabResult struct {
+	TabID  string `json:"id"`
+	Status string `json:"status"`          // one of the TabStatus values
+	Error  string `json:"error,omitempty"` // why the operation failed
+}
+
+// Statuses of a TabResult
+const (
+	// TabStatusOK marks a tab the operation succeeded on
+	TabStatusOK = "ok"
+	// TabStatusNotFound marks a tab its browser does not have
+	TabStatusNotFound = "not_found"
+	// TabStatusUnavailable marks a tab whose browser is not connected or
+	// did not answer
+	TabStatusUnavailable = "unavailable"
+	// TabStatusError marks a tab the browser failed to act on
+	TabStatusError = "error"
+)
+
 // WordsOptions represents options for extracting words from tabs
 type WordsOptions struct {
 	MatchRegex string `json:"match_regex"`
@@ -114,4 +169,11 @@ const (
 	DefaultGetTextReplaceWith     = " "
 	DefaultGetHTMLDelimiterRegex  = `\n|\r|\t`
 	DefaultGetHTMLReplaceWith     = " "
-)
\ No newline at end of file
+)
+// DuplicateGroup is a set of tabs showing the same normalized URL. Keep is
+// the tab that stays open; the tabs in Close are its duplicates.
+type DuplicateGroup struct {
+	URL   string `json:"url"` // normalized URL
+	Keep  Tab    `json:"keep"`
+	Close []Tab  `json:"close"`
+}